@@ -0,0 +1,209 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/bundle"
+)
+
+// commandUsage is shown when a subcommand is used incorrectly
+const commandUsage = `Usage:
+  terraform-provider-alzlib keygen <name>            write a new key pair to <name>.pub and <name>.key
+  terraform-provider-alzlib pack [-key <file>] <dir> write the manifest of the library in <dir>, and sign it if a key is supplied
+  terraform-provider-alzlib sign -key <file> <dir>   sign the manifest of the library in <dir>
+  terraform-provider-alzlib lint [-catalog <file>] [-severity <check>=<level>]... <dir>...
+                                                     report the problems in the library and its unused objects and files`
+
+// runCommand runs the subcommand in the supplied arguments.
+// It returns false if the arguments are not a subcommand, so the provider should be served.
+func runCommand(args []string, stdout io.Writer) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	key := fs.String("key", "", "file containing the base64 encoded ed25519 private key")
+	catalog := fs.String("catalog", "", "file containing the built-in policy catalog")
+	severities := severityFlag{}
+	fs.Var(severities, "severity", "the severity of a hygiene check, e.g. empty_archetype=off")
+
+	switch args[0] {
+	case "keygen":
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			return true, fmt.Errorf("%s", commandUsage)
+		}
+		return true, keygen(fs.Arg(0), stdout)
+	case "pack":
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			return true, fmt.Errorf("%s", commandUsage)
+		}
+		if err := pack(fs.Arg(0), stdout); err != nil {
+			return true, err
+		}
+		if *key == "" {
+			return true, nil
+		}
+		return true, sign(fs.Arg(0), *key, stdout)
+	case "sign":
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 || *key == "" {
+			return true, fmt.Errorf("%s", commandUsage)
+		}
+		return true, sign(fs.Arg(0), *key, stdout)
+	case "lint":
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() == 0 {
+			return true, fmt.Errorf("%s", commandUsage)
+		}
+		return true, lint(fs.Args(), *catalog, severities, stdout)
+	}
+	return false, nil
+}
+
+// commandExitStatus returns the exit status of a subcommand that returned the supplied error, which is written to stderr
+func commandExitStatus(err error, stderr io.Writer) int {
+	if err == nil {
+		return 0
+	}
+	fmt.Fprintln(stderr, err)
+	return 1
+}
+
+// keygen writes a new key pair, the private key file is only readable by the user
+func keygen(name string, stdout io.Writer) error {
+	pub, priv, err := bundle.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(name+".key", []byte(priv+"\n"), 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(name+".pub", []byte(pub+"\n"), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote %s.key and %s.pub, add the public key to the provider trusted_keys: %s\n", name, name, pub)
+	return nil
+}
+
+// pack writes the manifest of the library in the supplied directory.
+// The files are selected in the same way as when the library is loaded, e.g. hidden directories are skipped.
+func pack(dir string, stdout io.Writer) error {
+	l, err := alzlib.DirLayer(dir)
+	if err != nil {
+		return err
+	}
+	hashes, err := alzlib.HashLayer(l)
+	if err != nil {
+		return err
+	}
+	m := bundle.Pack(hashes)
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(dir, bundle.ManifestFileName)
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote %s with %d files\n", p, len(m.Files))
+	return nil
+}
+
+// sign writes the signature of the manifest of the library in the supplied directory
+func sign(dir, keyFile string, stdout io.Writer) error {
+	keyData, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := bundle.ParsePrivateKey(string(keyData))
+	if err != nil {
+		return fmt.Errorf("%s: %s", keyFile, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, bundle.ManifestFileName))
+	if err != nil {
+		return fmt.Errorf("unable to read the manifest, run pack first: %s", err)
+	}
+	p := filepath.Join(dir, bundle.SignatureFileName)
+	if err := os.WriteFile(p, bundle.Sign(data, key), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote %s\n", p)
+	return nil
+}
+
+// lint writes the problems in the library, which is made of the supplied layers, and the hygiene findings.
+// It returns an error if there are any problems or hygiene findings with the error severity.
+func lint(dirs []string, catalogFile string, severities severityFlag, stdout io.Writer) error {
+	var catalog *alzlib.BuiltInCatalog
+	if catalogFile != "" {
+		var err error
+		if catalog, err = alzlib.ReadBuiltInCatalog(catalogFile); err != nil {
+			return err
+		}
+	}
+	layers := make([]alzlib.LibLayer, len(dirs))
+	for i, dir := range dirs {
+		l, err := alzlib.NewLayer(dir)
+		if err != nil {
+			return err
+		}
+		layers[i] = l
+	}
+	az, findings, err := alzlib.ValidateLayers(catalog, layers...)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[alzlib.Severity]int)
+	for _, f := range findings {
+		sev := alzlib.SeverityError
+		if f.Warning {
+			sev = alzlib.SeverityWarning
+		}
+		counts[sev]++
+		if f.File == "" {
+			fmt.Fprintf(stdout, "%s: %s\n", sev, f.Message)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: %s%s: %s\n", sev, f.File, f.Pointer, f.Message)
+	}
+	for _, f := range az.Hygiene(severities) {
+		counts[f.Severity]++
+		fmt.Fprintf(stdout, "%s: %s\n", f.Severity, f)
+	}
+
+	fmt.Fprintf(stdout, "%d errors, %d warnings, %d info\n", counts[alzlib.SeverityError], counts[alzlib.SeverityWarning], counts[alzlib.SeverityInfo])
+	if counts[alzlib.SeverityError] > 0 {
+		return fmt.Errorf("the library has %d errors", counts[alzlib.SeverityError])
+	}
+	return nil
+}
+
+// severityFlag is a repeatable flag that sets the severity of a hygiene check, e.g. -severity empty_archetype=off
+type severityFlag map[alzlib.HygieneCheck]alzlib.Severity
+
+func (s severityFlag) String() string {
+	return ""
+}
+
+func (s severityFlag) Set(v string) error {
+	check, level, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("the severity must be <check>=<level>, got %s", v)
+	}
+	c, err := alzlib.ParseHygieneCheck(check)
+	if err != nil {
+		return err
+	}
+	sev, err := alzlib.ParseSeverity(level)
+	if err != nil {
+		return err
+	}
+	s[c] = sev
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/bundle"
+)
+
+// writeFiles writes the supplied files, keyed by slash separated path, to the directory
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunCommandNotACommand(t *testing.T) {
+	for _, args := range [][]string{nil, {"-debug"}, {"serve"}} {
+		handled, err := runCommand(args, new(bytes.Buffer))
+		if handled || err != nil {
+			t.Errorf("expected %v not to be handled, got %t, %v", args, handled, err)
+		}
+	}
+}
+
+func TestRunCommandUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"keygen"},
+		{"keygen", "a", "b"},
+		{"pack"},
+		{"sign", "./lib"},
+		{"lint"},
+		{"lint", "-severity", "unknown_check=error", "./lib"},
+		{"lint", "-severity", "empty_archetype=fatal", "./lib"},
+	} {
+		handled, err := runCommand(args, new(bytes.Buffer))
+		if !handled || err == nil || !strings.HasPrefix(err.Error(), "Usage:") {
+			t.Errorf("expected the usage error for %v, got %t, %v", args, handled, err)
+		}
+	}
+}
+
+// TestKeygenPackSign tests that a library that is packed and signed with a generated key is trusted,
+// and that the manifest lists the files that are loaded
+func TestKeygenPackSign(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	writeFiles(t, lib, map[string]string{
+		"policy_definition_a.json":     `{"name": "a"}`,
+		"sub/policy_definition_b.json": `{"name": "b"}`,
+		"README.md":                    "lib",
+		".git/config":                  "hidden",
+	})
+	name := filepath.Join(dir, "release")
+	stdout := new(bytes.Buffer)
+
+	if _, err := runCommand([]string{"keygen", name}, stdout); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(name + ".key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("expected the private key to only be readable by the user, got %s", fi.Mode().Perm())
+	}
+	pubData, err := os.ReadFile(name + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := bundle.ParsePublicKey(string(pubData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sign needs the manifest
+	if _, err := runCommand([]string{"sign", "-key", name + ".key", lib}, stdout); err == nil || !strings.Contains(err.Error(), "run pack first") {
+		t.Fatalf("expected an error as there is no manifest, got %v", err)
+	}
+
+	if _, err := runCommand([]string{"pack", "-key", name + ".key", lib}, stdout); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "with 3 files") {
+		t.Errorf("expected the manifest to have 3 files, got %s", stdout)
+	}
+	m, err := bundle.ReadManifest(os.DirFS(lib), []ed25519.PublicKey{pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range m.Files {
+		paths = append(paths, f.Path)
+	}
+	if strings.Join(paths, ",") != "README.md,policy_definition_a.json,sub/policy_definition_b.json" {
+		t.Errorf("expected the manifest to list the files that are loaded, got %v", paths)
+	}
+	az, err := alzlib.NewAlzLib(lib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := make(map[string]string)
+	for _, f := range az.Files() {
+		hashes[f.Path] = f.Sha256
+	}
+	if err := m.Verify(hashes, alzlib.IsLibFile); err != nil {
+		t.Errorf("expected the loaded files to match the manifest: %s", err)
+	}
+
+	// the manifest is signed again with another key, which is not trusted
+	other := filepath.Join(dir, "other")
+	if _, err := runCommand([]string{"keygen", other}, stdout); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCommand([]string{"sign", "-key", other + ".key", lib}, stdout); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bundle.ReadManifest(os.DirFS(lib), []ed25519.PublicKey{pub}); err == nil || !strings.Contains(err.Error(), "not from a trusted key") {
+		t.Errorf("expected the signature to be from an untrusted key, got %v", err)
+	}
+
+	if _, err := runCommand([]string{"sign", "-key", name + ".pub", lib}, stdout); err == nil || !strings.Contains(err.Error(), "invalid private key") {
+		t.Errorf("expected an error signing with the public key, got %v", err)
+	}
+}
+
+// TestLint tests the findings that are reported by the lint command, with their severities, and its exit status
+func TestLint(t *testing.T) {
+	dir := "internal/alzlib/testdata/hygiene/"
+	unreferenced := dir + "policy_definition_unused.json: policy definition unused is not referenced by any archetype (unreferenced_policy_definition)"
+	unknownPrefix := dir + "policy_defintion_typo.json: the file was ignored as its name does not start with a lib file prefix (unknown_prefix)"
+	empty := dir + "archetype_definition_empty.json: archetype empty is empty (empty_archetype)"
+
+	cases := []struct {
+		name       string
+		args       []string
+		want       []string
+		notWant    []string
+		summary    string
+		exitStatus int
+	}{
+		{
+			name:       "default severities",
+			want:       []string{"warning: " + unreferenced, "warning: " + unknownPrefix, "warning: " + empty},
+			summary:    "0 errors, 6 warnings, 0 info",
+			exitStatus: 0,
+		},
+		{
+			name:       "misspelt prefix is an error",
+			args:       []string{"-severity", "unknown_prefix=error"},
+			want:       []string{"warning: " + unreferenced, "error: " + unknownPrefix, "warning: " + empty},
+			summary:    "1 errors, 5 warnings, 0 info",
+			exitStatus: 1,
+		},
+		{
+			name:       "configured severities",
+			args:       []string{"-severity", "unreferenced_policy_definition=info", "-severity", "empty_archetype=off"},
+			want:       []string{"info: " + unreferenced, "warning: " + unknownPrefix},
+			notWant:    []string{empty},
+			summary:    "0 errors, 4 warnings, 1 info",
+			exitStatus: 0,
+		},
+		{
+			name:       "unreferenced definition and empty archetype are errors",
+			args:       []string{"-severity", "unreferenced_policy_definition=error", "-severity", "empty_archetype=error", "-severity", "unknown_prefix=off"},
+			want:       []string{"error: " + unreferenced, "error: " + empty},
+			notWant:    []string{unknownPrefix},
+			summary:    "2 errors, 3 warnings, 0 info",
+			exitStatus: 1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			handled, err := runCommand(append(append([]string{"lint"}, c.args...), dir), stdout)
+			if !handled {
+				t.Fatal("expected lint to be handled")
+			}
+			if status := commandExitStatus(err, stderr); status != c.exitStatus {
+				t.Errorf("expected exit status %d, got %d: %s", c.exitStatus, status, stderr)
+			}
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			for _, w := range c.want {
+				if !containsLine(lines, w) {
+					t.Errorf("expected the finding %q, got:\n%s", w, stdout)
+				}
+			}
+			for _, nw := range c.notWant {
+				if strings.Contains(stdout.String(), nw) {
+					t.Errorf("expected the finding %q not to be reported, got:\n%s", nw, stdout)
+				}
+			}
+			if lines[len(lines)-1] != c.summary {
+				t.Errorf("expected the summary %q, got %q", c.summary, lines[len(lines)-1])
+			}
+		})
+	}
+
+	// problems in the library are reported as errors, before the hygiene findings
+	stdout := new(bytes.Buffer)
+	_, err := runCommand([]string{"lint", "-severity", "empty_archetype=off", "internal/alzlib/testdata/badlib-validation"}, stdout)
+	if status := commandExitStatus(err, new(bytes.Buffer)); status != 1 {
+		t.Errorf("expected exit status 1 for a library with problems, got %d", status)
+	}
+	if !strings.HasPrefix(stdout.String(), "error: internal/alzlib/testdata/badlib-validation/") {
+		t.Errorf("expected the problems to be reported first, got:\n%s", stdout)
+	}
+}
+
+// containsLine returns true if one of the lines is the supplied line
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+data "alzlib_archetype" "example" {
+  base_archetype               = "es_corp"
+  policy_assignments_to_add    = ["Deny-Resource-Locations"]
+  policy_assignments_to_remove = ["Deny-DataB-Pip"]
+
+  policy_assignment_parameters = {
+    Deny-Resource-Locations = jsonencode({
+      listOfAllowedLocations = ["uksouth", "ukwest"]
+    })
+  }
+
+  template_variables = {
+    root_scope_id    = "alz"
+    current_scope_id = "alz-corp"
+    default_location = "uksouth"
+  }
+}
@@ -1 +1,8 @@
 data "alzlib_archetypes" "test" {}
+
+# Only the platform archetypes, with each definition returned once and without the policy rules
+data "alzlib_archetypes" "platform" {
+  names                   = ["es_root", "es_platform", "es_connectivity"]
+  include_policy_rules    = false
+  deduplicate_definitions = true
+}
@@ -0,0 +1,10 @@
+data "alzlib_hygiene" "example" {
+  severities = {
+    unknown_prefix  = "error"
+    empty_archetype = "off"
+  }
+}
+
+output "unused_library_objects" {
+  value = [for f in data.alzlib_hygiene.example.findings : "${f.file}: ${f.message}"]
+}
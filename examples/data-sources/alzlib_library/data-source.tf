@@ -0,0 +1,5 @@
+data "alzlib_library" "example" {}
+
+output "embedded_library_version" {
+  value = data.alzlib_library.example.embedded_version
+}
@@ -0,0 +1,23 @@
+data "alzlib_management_group_hierarchy" "example" {
+  root_parent_id = "00000000-0000-0000-0000-000000000000"
+
+  management_groups = {
+    alz = {
+      parent_id = "00000000-0000-0000-0000-000000000000"
+      archetype = "es_root"
+    }
+    alz-landing-zones = {
+      parent_id = "alz"
+      archetype = "es_landing_zones"
+    }
+    alz-corp = {
+      parent_id = "alz-landing-zones"
+      archetype = "es_corp"
+    }
+  }
+
+  template_variables = {
+    default_location        = "uksouth"
+    private_dns_zone_prefix = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/dns/providers/Microsoft.Network/privateDnsZones/"
+  }
+}
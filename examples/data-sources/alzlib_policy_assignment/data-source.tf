@@ -0,0 +1,8 @@
+data "alzlib_policy_assignment" "example" {
+  name = "Deny-DataB-Pip"
+  template_variables = {
+    root_scope_id    = "alz"
+    current_scope_id = "alz-corp"
+    default_location = "uksouth"
+  }
+}
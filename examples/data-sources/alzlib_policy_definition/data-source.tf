@@ -0,0 +1,3 @@
+data "alzlib_policy_definition" "example" {
+  name = "Deny-Subnet-Without-Nsg"
+}
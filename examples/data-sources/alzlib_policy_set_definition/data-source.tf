@@ -0,0 +1,3 @@
+data "alzlib_policy_set_definition" "example" {
+  name = "Deploy-MDFC-Config"
+}
@@ -0,0 +1,5 @@
+data "alzlib_role_definitions" "example" {
+  template_variables = {
+    current_scope_id = "alz"
+  }
+}
@@ -0,0 +1,9 @@
+provider "alzlib" {
+  validation_mode = "warning"
+}
+
+data "alzlib_validation" "example" {}
+
+output "library_problems" {
+  value = [for f in data.alzlib_validation.example.findings : "${f.file}${f.pointer}: ${f.message}"]
+}
@@ -0,0 +1,103 @@
+package alzlib
+
+import (
+	"fmt"
+)
+
+// ArchetypeOverride describes changes that are made to a copy of an archetype,
+// in the same way as the archetype extension and exclusion lib files.
+// The additions are made first, then the parameters are set, then the removals are made.
+type ArchetypeOverride struct {
+	PolicyAssignmentsToAdd       []string
+	PolicyAssignmentsToRemove    []string
+	PolicyDefinitionsToAdd       []string
+	PolicyDefinitionsToRemove    []string
+	PolicySetDefinitionsToAdd    []string
+	PolicySetDefinitionsToRemove []string
+	RoleDefinitionsToAdd         []string
+	RoleDefinitionsToRemove      []string
+	// Parameters maps the policy assignment name to the parameter values that replace those in the assignment,
+	// in the same format as archetype_config.parameters
+	Parameters map[string]map[string]interface{}
+}
+
+// OverrideArchetype returns a copy of the named archetype with the supplied overrides applied.
+// The archetype in the AlzLib is not modified.
+func (az *AlzLib) OverrideArchetype(name string, o ArchetypeOverride) (*ArchetypeDefinition, error) {
+	base, ok := az.Archetypes[name]
+	if !ok {
+		return nil, fmt.Errorf("archetype %s not found", name)
+	}
+	ad, err := base.copy()
+	if err != nil {
+		return nil, err
+	}
+
+	params := make(map[string]interface{}, len(o.Parameters))
+	for k, v := range o.Parameters {
+		params[k] = v
+	}
+	add := &LibArchetypeDefinition{
+		Id:                   name,
+		PolicyAssignments:    o.PolicyAssignmentsToAdd,
+		PolicyDefinitions:    o.PolicyDefinitionsToAdd,
+		PolicySetDefinitions: o.PolicySetDefinitionsToAdd,
+		RoleDefinitions:      o.RoleDefinitionsToAdd,
+		Config: &libArchetypeDefinitionConfig{
+			Parameters: params,
+		},
+	}
+	if err := ad.AddLibArchetype(add); err != nil {
+		return nil, err
+	}
+
+	remove := &LibArchetypeDefinition{
+		Id:                   name,
+		PolicyAssignments:    o.PolicyAssignmentsToRemove,
+		PolicyDefinitions:    o.PolicyDefinitionsToRemove,
+		PolicySetDefinitions: o.PolicySetDefinitionsToRemove,
+		RoleDefinitions:      o.RoleDefinitionsToRemove,
+	}
+	if err := ad.RemoveLibArchetype(remove); err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// copy returns a deep copy of the archetype definition,
+// so that objects can be added, removed and modified without affecting the original
+func (ad *ArchetypeDefinition) copy() (*ArchetypeDefinition, error) {
+	result := newArchetypeDefinition(ad.AlzLib)
+	for k, v := range ad.PolicyDefinitions {
+		c, err := deepCopy(&v)
+		if err != nil {
+			return nil, fmt.Errorf("error copying policy definition %s: %s", k, err)
+		}
+		result.PolicyDefinitions[k] = *c
+	}
+	for k, v := range ad.PolicyAssignments {
+		c, err := deepCopy(&v)
+		if err != nil {
+			return nil, fmt.Errorf("error copying policy assignment %s: %s", k, err)
+		}
+		result.PolicyAssignments[k] = *c
+	}
+	for k, v := range ad.PolicySetDefinitions {
+		c, err := deepCopy(&v)
+		if err != nil {
+			return nil, fmt.Errorf("error copying policy set definition %s: %s", k, err)
+		}
+		result.PolicySetDefinitions[k] = *c
+	}
+	for k, v := range ad.RoleDefinitions {
+		c, err := deepCopy(&v)
+		if err != nil {
+			return nil, fmt.Errorf("error copying role definition %s: %s", k, err)
+		}
+		result.RoleDefinitions[k] = *c
+	}
+	for k, v := range ad.RoleAssignments {
+		result.RoleAssignments[k] = v
+	}
+	return result, nil
+}
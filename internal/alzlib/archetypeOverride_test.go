@@ -0,0 +1,64 @@
+package alzlib
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestOverrideArchetype tests that the overrides are applied to a copy of the archetype
+// and that the archetype and policy assignment in the library are unchanged
+func TestOverrideArchetype(t *testing.T) {
+	az, err := NewAlzLib(testLibDir)
+	assert.NilError(t, err)
+	baseCount := len(az.Archetypes["es_corp"].PolicyAssignments)
+
+	ad, err := az.OverrideArchetype("es_corp", ArchetypeOverride{
+		PolicyAssignmentsToAdd:    []string{"Deny-Resource-Locations"},
+		PolicyAssignmentsToRemove: []string{"Deny-DataB-Pip"},
+		Parameters: map[string]map[string]interface{}{
+			"Deny-Resource-Locations": {
+				"listOfAllowedLocations": []interface{}{"uksouth"},
+			},
+		},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(ad.PolicyAssignments), baseCount)
+	_, exists := ad.PolicyAssignments["Deny-DataB-Pip"]
+	assert.Assert(t, !exists)
+	assert.DeepEqual(t, ad.PolicyAssignments["Deny-Resource-Locations"].Properties.Parameters["listOfAllowedLocations"].Value, []interface{}{"uksouth"})
+
+	_, exists = az.Archetypes["es_corp"].PolicyAssignments["Deny-DataB-Pip"]
+	assert.Assert(t, exists)
+	_, exists = az.Archetypes["es_corp"].PolicyAssignments["Deny-Resource-Locations"]
+	assert.Assert(t, !exists)
+	assert.DeepEqual(t, az.PolicyAssignments["Deny-Resource-Locations"].Properties.Parameters["listOfAllowedLocations"].Value, []interface{}{"uksouth", "ukwest"})
+
+	// the objects in the copy are not shared with the archetype
+	displayName := "changed"
+	ad.PolicyAssignments["Deny-DataB-Sku"].Properties.DisplayName = &displayName
+	assert.Assert(t, *az.Archetypes["es_corp"].PolicyAssignments["Deny-DataB-Sku"].Properties.DisplayName != displayName)
+}
+
+// TestOverrideArchetypeErrors tests that the existing archetype error messages are returned
+func TestOverrideArchetypeErrors(t *testing.T) {
+	az, err := NewAlzLib(testLibDir)
+	assert.NilError(t, err)
+
+	_, err = az.OverrideArchetype("es_unknown", ArchetypeOverride{})
+	assert.ErrorContains(t, err, "archetype es_unknown not found")
+
+	_, err = az.OverrideArchetype("es_corp", ArchetypeOverride{PolicyDefinitionsToAdd: []string{"Not-A-Definition"}})
+	assert.ErrorContains(t, err, "policy definition Not-A-Definition not found for archetype es_corp")
+
+	_, err = az.OverrideArchetype("es_corp", ArchetypeOverride{PolicyAssignmentsToAdd: []string{"Deny-DataB-Pip"}})
+	assert.ErrorContains(t, err, "duplicate policy assignment in archetype es_corp: Deny-DataB-Pip")
+
+	_, err = az.OverrideArchetype("es_corp", ArchetypeOverride{PolicySetDefinitionsToRemove: []string{"Deploy-MDFC-Config"}})
+	assert.ErrorContains(t, err, "cannot exclude policy set Deploy-MDFC-Config from archetype es_corp as it does not exist")
+
+	_, err = az.OverrideArchetype("es_corp", ArchetypeOverride{
+		Parameters: map[string]map[string]interface{}{"Deny-DataB-Pip": {"notAParameter": "x"}},
+	})
+	assert.ErrorContains(t, err, "archetype_config.parameters error: cannot modify policy parameter notAParameter")
+}
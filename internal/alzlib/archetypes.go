@@ -0,0 +1,330 @@
+package alzlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+)
+
+// generateArchetypes generates the archetype definitions from the supplied data
+// in the libArchetypeDefinitions struct, PolicyDefinitions, PolicySetDefinitions, and PolicyAssignments maps.
+// It does not stop at the first problem, the error is a ValidationError that lists all of them.
+func (az *AlzLib) generateArchetypes() error {
+	var findings []Finding
+
+	// Generate the initial ArchetypeDefinitions from the libArchetypeDefinitions map
+	for _, lad := range az.libArchetypeDefinitions {
+		// create the archetype and add it to the AlzLib
+		if _, exists := az.Archetypes[lad.Id]; exists {
+			findings = append(findings, Finding{File: lad.file, Pointer: lad.pointer, Message: fmt.Sprintf("duplicate archetype id: %s", lad.Id)})
+			continue
+		}
+
+		// Create the new archetype and add it to the AlzLib struct, then process the lib archetype definition
+		// to populate the new archetype definition.
+		//
+		// This process will create deep copies of the policy definitions, policy set definitions, policy assignments and role definitions.
+		// They will then be modified based on the archetype_config in the lib archetype definition
+		az.Archetypes[lad.Id] = newArchetypeDefinition(az)
+		if err := az.Archetypes[lad.Id].AddLibArchetype(lad); err != nil {
+			findings = append(findings, errorFindings(err, lad.file, lad.pointer)...)
+		}
+	}
+
+	// Extend the ArchetypeDefinitions with the libArchetypeExtensions slice
+	for _, ext := range az.libArchetypeExtensions {
+		ad, ok := az.Archetypes[ext.Id]
+		if !ok {
+			findings = append(findings, Finding{File: ext.file, Pointer: ext.pointer, Message: fmt.Sprintf("cannot extend archetype %s as it does not exist", ext.Id)})
+			continue
+		}
+		if err := ad.AddLibArchetype(ext); err != nil {
+			findings = append(findings, errorFindings(err, ext.file, ext.pointer)...)
+		}
+	}
+
+	// Exclude from the ArchetypeDefinitions with the libArchetypeExclusions slice
+	for _, excl := range az.libArchetypeExclusions {
+		ad, ok := az.Archetypes[excl.Id]
+		if !ok {
+			findings = append(findings, Finding{File: excl.file, Pointer: excl.pointer, Message: fmt.Sprintf("cannot exclude from archetype %s as it does not exist", excl.Id)})
+			continue
+		}
+		if err := ad.RemoveLibArchetype(excl); err != nil {
+			findings = append(findings, errorFindings(err, excl.file, excl.pointer)...)
+		}
+	}
+	return findingsError(findings)
+}
+
+// newArchetypeDefinition creates a new archetype definition linked to the supplied AlzLib
+func newArchetypeDefinition(az *AlzLib) *ArchetypeDefinition {
+	return &ArchetypeDefinition{
+		AlzLib:               az,
+		PolicyDefinitions:    make(map[string]armpolicy.Definition),
+		PolicyAssignments:    make(map[string]armpolicy.Assignment),
+		PolicySetDefinitions: make(map[string]armpolicy.SetDefinition),
+		RoleDefinitions:      make(map[string]RoleDefinition),
+		RoleAssignments:      make(map[string]RoleAssignment),
+	}
+}
+
+// AddLibArchetype method adds the supplied lib archetype definition to the archetype definition.
+// This is used at the initial processing of the lib directory as well as for archetype extensions.
+// The library objects are deep copied, so the archetype_config parameters do not affect the library or other archetypes.
+// Objects that cannot be added are skipped, the error is a ValidationError that lists all of them.
+func (ad *ArchetypeDefinition) AddLibArchetype(lad *LibArchetypeDefinition) error {
+	var findings []Finding
+	addFinding := func(pointer string, format string, a ...interface{}) {
+		findings = append(findings, Finding{File: lad.file, Pointer: lad.pointer + pointer, Message: fmt.Sprintf(format, a...)})
+	}
+
+	// add the policy set definitions to the Archetype struct
+	// range over the strings in in the libArchetypeDefinition array
+	for i, ps := range lad.PolicySetDefinitions {
+		if _, exists := ad.PolicySetDefinitions[ps]; exists {
+			addFinding(jsonPointer("policy_set_definitions", i), "duplicate policy set definition in archetype %s: %s", lad.Id, ps)
+			continue
+		}
+		// look up the policy set definition to check we have it in the library
+		p, ok := ad.AlzLib.PolicySetDefinitions[ps]
+		if !ok {
+			addFinding(jsonPointer("policy_set_definitions", i), "policy set definition %s not found for archetype %s", ps, lad.Id)
+			continue
+		}
+		c, err := deepCopy(p)
+		if err != nil {
+			addFinding(jsonPointer("policy_set_definitions", i), "error copying policy set definition %s for archetype %s: %s", ps, lad.Id, err)
+			continue
+		}
+		ad.PolicySetDefinitions[ps] = *c
+	}
+
+	// add the policy definitions to the Archetype struct
+	// range over the strings in in the libArchetypeDefinition array
+	for i, pd := range lad.PolicyDefinitions {
+		if _, exists := ad.PolicyDefinitions[pd]; exists {
+			addFinding(jsonPointer("policy_definitions", i), "duplicate policy definition in archetype %s: %s", lad.Id, pd)
+			continue
+		}
+		// look up the policy definitions to check we have it in the library
+		p, ok := ad.AlzLib.PolicyDefinitions[pd]
+		if !ok {
+			addFinding(jsonPointer("policy_definitions", i), "policy definition %s not found for archetype %s", pd, lad.Id)
+			continue
+		}
+		c, err := deepCopy(p)
+		if err != nil {
+			addFinding(jsonPointer("policy_definitions", i), "error copying policy definition %s for archetype %s: %s", pd, lad.Id, err)
+			continue
+		}
+		ad.PolicyDefinitions[pd] = *c
+	}
+
+	// add the policy assignments to the Archetype struct
+	// range over the strings in in the libArchetypeDefinition array
+	for i, pa := range lad.PolicyAssignments {
+		if _, exists := ad.PolicyAssignments[pa]; exists {
+			addFinding(jsonPointer("policy_assignments", i), "duplicate policy assignment in archetype %s: %s", lad.Id, pa)
+			continue
+		}
+		// look up the policy assignment to check we have it in the library
+		p, ok := ad.AlzLib.PolicyAssignments[pa]
+		if !ok {
+			addFinding(jsonPointer("policy_assignments", i), "policy assignment %s not found for archetype %s", pa, lad.Id)
+			continue
+		}
+		c, err := deepCopy(p)
+		if err != nil {
+			addFinding(jsonPointer("policy_assignments", i), "error copying policy assignment %s for archetype %s: %s", pa, lad.Id, err)
+			continue
+		}
+		ad.PolicyAssignments[pa] = *c
+	}
+
+	// add the role definitions to the Archetype struct
+	// range over the strings in in the libArchetypeDefinition array
+	for i, rd := range lad.RoleDefinitions {
+		if _, exists := ad.RoleDefinitions[rd]; exists {
+			addFinding(jsonPointer("role_definitions", i), "duplicate role definition in archetype %s: %s", lad.Id, rd)
+			continue
+		}
+		// look up the role definition to check we have it in the library
+		r, ok := ad.AlzLib.RoleDefinitions[rd]
+		if !ok {
+			addFinding(jsonPointer("role_definitions", i), "role definition %s not found for archetype %s", rd, lad.Id)
+			continue
+		}
+		c, err := deepCopy(r)
+		if err != nil {
+			addFinding(jsonPointer("role_definitions", i), "error copying role definition %s for archetype %s: %s", rd, lad.Id, err)
+			continue
+		}
+		ad.RoleDefinitions[rd] = *c
+	}
+
+	// Update policy assignment properties with any defined in the archetype config
+	// range over the parameters map, getting the name of the policy assignment using the key.
+	// The keys are sorted so that the findings are deterministic.
+	if lad.Config != nil && lad.Config.Parameters != nil {
+		for _, policy := range sortedKeys(lad.Config.Parameters) {
+			pointer := jsonPointer("archetype_config", "parameters", policy)
+			// for each key, check if we have the same key in the az.Archetypes[lad.id].PolicyAssignments map
+			if _, exists := ad.PolicyAssignments[policy]; !exists {
+				addFinding(pointer, "archetype_config.parameters error: cannot modify policy assignment %s, in archetype %s. policy %s does not exist", policy, lad.Id, policy)
+				continue
+			}
+
+			// if we do, use type assertion to a get a map[string]{interface} and range over that map, the key being the parameter name and the value being the parameter value
+			params, ok := lad.Config.Parameters[policy].(map[string]interface{})
+			if !ok {
+				addFinding(pointer, "policy assignment %s parameters are not a map", policy)
+				continue
+			}
+
+			// the policy assignment is a copy that belongs to this archetype, so it can be modified in place
+			pa := ad.PolicyAssignments[policy]
+			if pa.Properties == nil {
+				pa.Properties = &armpolicy.AssignmentProperties{}
+			}
+
+			// the new values are checked against the parameter schema of the policy definition, if it is known.
+			// Problems with the policy definition id are reported for the policy assignment file.
+			var schema map[string]*armpolicy.ParameterDefinitionsValue
+			if pa.Properties.PolicyDefinitionID != nil {
+				schema, _, _ = ad.AlzLib.parameterSchema(*pa.Properties.PolicyDefinitionID)
+			}
+
+			// range over the parameters
+			for _, pk := range sortedKeys(params) {
+				// and test if the Policy Assignment.Properties.Parameters map has the same key (the parameter name)
+				if _, exists := pa.Properties.Parameters[pk]; !exists {
+					addFinding(pointer+jsonPointer(pk), "archetype_config.parameters error: cannot modify policy parameter %s in assignment %s, in archetype %s. parameter %s does not exist", pk, policy, lad.Id, pk)
+					continue
+				}
+
+				def, _ := lookupParameter(schema, pk)
+				if err := checkParameterValue(def, params[pk]); err != nil {
+					addFinding(pointer+jsonPointer(pk), "archetype_config.parameters error: parameter %s in assignment %s, in archetype %s %s", pk, policy, lad.Id, err)
+					continue
+				}
+
+				// if it does, create a new ParameterValuesValue, set the Value field to the value of the parameter in the archetype config
+				// and set the ParameterValuesValue in the Policy Assignment.Properties.Parameters map to the new ParameterValuesValue
+				pa.Properties.Parameters[pk] = &armpolicy.ParameterValuesValue{Value: params[pk]}
+			}
+			ad.PolicyAssignments[policy] = pa
+		}
+	}
+
+	// Add the role assignments defined in the archetype config access_control map
+	// the roles are validated against the library and built-in role definitions
+	if lad.Config != nil && lad.Config.AccessControl != nil {
+		ras, err := ad.AlzLib.generateRoleAssignments(lad.Id, lad.Config.AccessControl)
+		if err != nil {
+			findings = append(findings, errorFindings(err, lad.file, lad.pointer+jsonPointer("archetype_config", "access_control"))...)
+		}
+		for rak, rav := range ras {
+			ad.RoleAssignments[rak] = rav
+		}
+	}
+	return findingsError(findings)
+}
+
+// RemoveLibArchetype method removed the supplied lib archetype definition from the archetype definition.
+// This is used or archetype exclusions.
+// Objects that cannot be removed are skipped, the error is a ValidationError that lists all of them.
+func (ad *ArchetypeDefinition) RemoveLibArchetype(lad *LibArchetypeDefinition) error {
+	var findings []Finding
+	addFinding := func(pointer string, format string, a ...interface{}) {
+		findings = append(findings, Finding{File: lad.file, Pointer: lad.pointer + pointer, Message: fmt.Sprintf(format, a...)})
+	}
+
+	// remove the policy set definitions to the Archetype struct
+	// range over the strings in in the libArchetypeDefinition array
+	for i, ps := range lad.PolicySetDefinitions {
+		if _, exists := ad.PolicySetDefinitions[ps]; !exists {
+			addFinding(jsonPointer("policy_set_definitions", i), "cannot exclude policy set %s from archetype %s as it does not exist", ps, lad.Id)
+			continue
+		}
+		// remove the policy set definition
+		delete(ad.PolicySetDefinitions, ps)
+	}
+
+	// add the policy definitions to the Archetype struct
+	// range over the strings in in the libArchetypeDefinition array
+	for i, pd := range lad.PolicyDefinitions {
+		if _, exists := ad.PolicyDefinitions[pd]; !exists {
+			addFinding(jsonPointer("policy_definitions", i), "cannot exclude policy definition %s from archetype %s as it does not exist", pd, lad.Id)
+			continue
+		}
+		// remove the policy definition
+		delete(ad.PolicyDefinitions, pd)
+	}
+
+	// add the policy assignments to the Archetype struct
+	// range over the strings in in the libArchetypeDefinition array
+	for i, pa := range lad.PolicyAssignments {
+		if _, exists := ad.PolicyAssignments[pa]; !exists {
+			addFinding(jsonPointer("policy_assignments", i), "cannot exclude policy assignment %s from archetype %s as it does not exist", pa, lad.Id)
+			continue
+		}
+		// remove the policy assignment
+		delete(ad.PolicyAssignments, pa)
+	}
+
+	// remove the role definitions from the Archetype struct
+	// range over the strings in in the libArchetypeDefinition array
+	for i, rd := range lad.RoleDefinitions {
+		if _, exists := ad.RoleDefinitions[rd]; !exists {
+			addFinding(jsonPointer("role_definitions", i), "cannot exclude role definition %s from archetype %s as it does not exist", rd, lad.Id)
+			continue
+		}
+		// remove the role definition
+		delete(ad.RoleDefinitions, rd)
+	}
+
+	// remove the role assignments defined in the archetype config access_control map
+	if lad.Config != nil && lad.Config.AccessControl != nil {
+		pointer := jsonPointer("archetype_config", "access_control")
+		ras, err := ad.AlzLib.generateRoleAssignments(lad.Id, lad.Config.AccessControl)
+		if err != nil {
+			findings = append(findings, errorFindings(err, lad.file, lad.pointer+pointer)...)
+		}
+		for rak, rav := range ras {
+			if _, exists := ad.RoleAssignments[rak]; !exists {
+				addFinding(pointer+jsonPointer(rav.RoleDefinitionName), "cannot exclude role assignment of %s to %s from archetype %s as it does not exist", rav.RoleDefinitionName, rav.PrincipalId, lad.Id)
+				continue
+			}
+			delete(ad.RoleAssignments, rak)
+		}
+	}
+	return findingsError(findings)
+}
+
+// deepCopy returns a copy of the supplied library object that shares no pointers, maps or slices with it,
+// so that the copy can be modified without affecting the library or other archetypes.
+// The object is marshalled to JSON and unmarshalled into a new object.
+func deepCopy[T any](in *T) (*T, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	out := new(T)
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// sortedKeys returns the keys of the supplied map in sorted order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+package alzlib
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// These are the file extensions of the supported library archives
+const archiveExtensionZip = ".zip"
+const archiveExtensionTarGz = ".tar.gz"
+const archiveExtensionTgz = ".tgz"
+
+// MaxArchiveEntries is the maximum number of entries that are read from a .tar.gz lib archive
+const MaxArchiveEntries = 10000
+
+// MaxArchiveUncompressedSize is the maximum total size of the files that are read from a .tar.gz lib archive.
+// The files are decompressed into memory, so this limits the memory used by an archive that decompresses to an excessive size.
+const MaxArchiveUncompressedSize = 256 << 20
+
+// IsArchive returns true if the supplied path has the extension of a supported library archive
+func IsArchive(p string) bool {
+	p = strings.ToLower(p)
+	return strings.HasSuffix(p, archiveExtensionZip) ||
+		strings.HasSuffix(p, archiveExtensionTarGz) ||
+		strings.HasSuffix(p, archiveExtensionTgz)
+}
+
+// NewLayer returns a LibLayer for the supplied path, which can be a directory or a library archive
+func NewLayer(p string) (LibLayer, error) {
+	if IsArchive(p) {
+		return ArchiveLayer(p, "")
+	}
+	return DirLayer(p)
+}
+
+// ArchiveLayer returns a LibLayer that reads the lib files from the supplied .zip or .tar.gz archive.
+// The archive is read into memory and is not extracted.
+// If checksum is not empty, it is the hex encoded SHA-256 of the archive and an error is returned if it does not match.
+func ArchiveLayer(p, checksum string) (LibLayer, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return LibLayer{}, fmt.Errorf("the supplied lib archive cannot be read: %s. %s", p, err)
+	}
+
+	if err := VerifyChecksum(data, checksum); err != nil {
+		return LibLayer{}, fmt.Errorf("lib archive %s: %w", p, err)
+	}
+
+	var zr *zip.Reader
+	switch lp := strings.ToLower(p); {
+	case strings.HasSuffix(lp, archiveExtensionZip):
+		zr, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	case strings.HasSuffix(lp, archiveExtensionTarGz), strings.HasSuffix(lp, archiveExtensionTgz):
+		zr, err = tarGzToZip(data, MaxArchiveEntries, MaxArchiveUncompressedSize)
+	default:
+		return LibLayer{}, fmt.Errorf("%s is not a supported lib archive, it must be a %s or %s file", p, archiveExtensionZip, archiveExtensionTarGz)
+	}
+	if err != nil {
+		return LibLayer{}, fmt.Errorf("error reading lib archive %s: %s", p, err)
+	}
+
+	return LibLayer{Name: p, FS: zr}, nil
+}
+
+// ChecksumMismatchError is returned when the SHA-256 of a lib archive is not the expected value
+type ChecksumMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+// Error implements the error interface
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("sha256 checksum mismatch, expected %s but the archive has %s", e.Expected, e.Actual)
+}
+
+// VerifyChecksum checks that the SHA-256 of the supplied data is the expected hex encoded value.
+// If the expected value is empty, the checksum is not verified.
+func VerifyChecksum(data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	return VerifySha256(hex.EncodeToString(sum[:]), expected)
+}
+
+// VerifySha256 checks that the supplied hex encoded SHA-256, e.g. of data that has been streamed, is the expected value.
+// If the expected value is empty, the checksum is not verified.
+func VerifySha256(actual, expected string) error {
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	if expected == "" {
+		return nil
+	}
+	if actual != expected {
+		return &ChecksumMismatchError{Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// tarGzToZip reads the regular files from the supplied .tar.gz data and returns them as an in-memory zip archive.
+// This means that both archive types can be read using the fs.FS implementation of zip.Reader.
+// An error is returned if the archive has more than maxEntries entries, or its files total more than maxSize bytes.
+func tarGzToZip(data []byte, maxEntries int, maxSize int64) (*zip.Reader, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	tr := tar.NewReader(gz)
+	entries := 0
+	remaining := maxSize
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entries++; entries > maxEntries {
+			return nil, fmt.Errorf("archive has more than %d entries", maxEntries)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("invalid file path in archive: %s", hdr.Name)
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		// the header size is not trusted, the copy is limited to one byte more than the remaining size to detect an excess
+		n, err := io.Copy(w, io.LimitReader(tr, remaining+1))
+		if err != nil {
+			return nil, err
+		}
+		if remaining -= n; remaining < 0 {
+			return nil, fmt.Errorf("archive files are larger than %d bytes when decompressed", maxSize)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
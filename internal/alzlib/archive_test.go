@@ -0,0 +1,145 @@
+package alzlib
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestArchiveLayerZip tests that the lib files are read from a zip archive, including from a sub directory
+func TestArchiveLayerZip(t *testing.T) {
+	p := writeTestArchive(t, "lib.zip", "./testdata/layers/base")
+	az, err := NewAlzLib(p)
+	assert.NilError(t, err)
+	assert.Equal(t, len(az.PolicyDefinitions), 2)
+	assert.Equal(t, len(az.Archetypes["test"].PolicyDefinitions), 1)
+	assert.Equal(t, az.SourceFile(KindPolicyDefinition, "test-policy"), filepath.Join(p, "lib", "policy_definition_test_policy.json"))
+}
+
+// TestArchiveLayerTarGz tests that the lib files are read from a tar.gz archive and that it can be layered
+func TestArchiveLayerTarGz(t *testing.T) {
+	p := writeTestArchive(t, "lib.tar.gz", "./testdata/layers/override")
+	az, err := NewAlzLib("./testdata/layers/base", p)
+	assert.NilError(t, err)
+	assert.Equal(t, *az.PolicyDefinitions["test-policy"].Properties.DisplayName, "override layer")
+	src, ok := az.Source(KindPolicyDefinition, "test-policy")
+	assert.Assert(t, ok)
+	assert.Equal(t, src.LayerPath, p)
+}
+
+// TestArchiveLayerChecksum tests that the checksum is verified
+func TestArchiveLayerChecksum(t *testing.T) {
+	p := writeTestArchive(t, "lib.zip", "./testdata/layers/base")
+	data, err := os.ReadFile(p)
+	assert.NilError(t, err)
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	_, err = ArchiveLayer(p, checksum)
+	assert.NilError(t, err)
+
+	_, err = ArchiveLayer(p, "0000")
+	assert.ErrorContains(t, err, "sha256 checksum mismatch, expected 0000 but the archive has "+checksum)
+}
+
+// TestTarGzToZipLimits tests that archives with too many entries, or that decompress to too many bytes, are rejected
+func TestTarGzToZipLimits(t *testing.T) {
+	data := tarGzBytes(t, map[string]int{"a.json": 600, "b.json": 500})
+
+	_, err := tarGzToZip(data, 2, 1100)
+	assert.NilError(t, err)
+
+	_, err = tarGzToZip(data, 1, 1100)
+	assert.ErrorContains(t, err, "archive has more than 1 entries")
+
+	_, err = tarGzToZip(data, 2, 1099)
+	assert.ErrorContains(t, err, "archive files are larger than 1099 bytes when decompressed")
+}
+
+// tarGzBytes returns a .tar.gz archive containing files of zeros with the supplied names and sizes
+func tarGzBytes(t *testing.T, files map[string]int) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(files[name]), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(make([]byte, files[name]))
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	assert.NilError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// TestIsArchive tests the detection of archives by file extension
+func TestIsArchive(t *testing.T) {
+	assert.Assert(t, IsArchive("lib.zip"))
+	assert.Assert(t, IsArchive("lib.TAR.GZ"))
+	assert.Assert(t, IsArchive("lib.tgz"))
+	assert.Assert(t, !IsArchive("lib"))
+	assert.Assert(t, !IsArchive("lib.tar"))
+}
+
+// writeTestArchive writes the files in the supplied directory to an archive in a temporary directory,
+// beneath a lib directory, and returns the path of the archive.
+// The archive type is determined by the file extension of the supplied name.
+func writeTestArchive(t *testing.T, name, dir string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(p)
+	assert.NilError(t, err)
+	defer f.Close()
+
+	entries, err := os.ReadDir(dir)
+	assert.NilError(t, err)
+
+	var add func(name string, data []byte) io.Writer
+	var closeFn func() error
+	if filepath.Ext(name) == ".zip" {
+		zw := zip.NewWriter(f)
+		add = func(name string, data []byte) io.Writer {
+			w, err := zw.Create(name)
+			assert.NilError(t, err)
+			return w
+		}
+		closeFn = zw.Close
+	} else {
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+		add = func(name string, data []byte) io.Writer {
+			assert.NilError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+			return tw
+		}
+		closeFn = func() error {
+			if err := tw.Close(); err != nil {
+				return err
+			}
+			return gz.Close()
+		}
+	}
+
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		assert.NilError(t, err)
+		_, err = add("lib/"+e.Name(), data).Write(data)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, closeFn())
+	return p
+}
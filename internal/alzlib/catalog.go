@@ -0,0 +1,82 @@
+package alzlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+)
+
+// BuiltInCatalog is an offline catalog of the built-in policy definitions,
+// which is used to check the built-in members of the policy set definitions
+type BuiltInCatalog struct {
+	// definitions are keyed by the lower case policy definition name
+	definitions map[string]*armpolicy.Definition
+}
+
+// NewBuiltInCatalog returns a catalog of the supplied built-in policy definitions
+func NewBuiltInCatalog(definitions []*armpolicy.Definition) (*BuiltInCatalog, error) {
+	c := &BuiltInCatalog{
+		definitions: make(map[string]*armpolicy.Definition, len(definitions)),
+	}
+	for i, pd := range definitions {
+		if pd == nil || pd.Name == nil || *pd.Name == "" {
+			return nil, fmt.Errorf("policy definition at index %d has no name", i)
+		}
+		c.definitions[strings.ToLower(*pd.Name)] = pd
+	}
+	return c, nil
+}
+
+// ReadBuiltInCatalog reads the catalog from the supplied file.
+// The file contains the built-in policy definitions, either as a JSON array,
+// e.g. the output of `az policy definition list`, or as the `value` of a REST API list response.
+func ReadBuiltInCatalog(p string) (*BuiltInCatalog, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("error reading built-in policy catalog %s: %s", p, err)
+	}
+	var definitions []*armpolicy.Definition
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		list := armpolicy.DefinitionListResult{}
+		err = json.Unmarshal(data, &list)
+		definitions = list.Value
+	} else {
+		err = json.Unmarshal(data, &definitions)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading built-in policy catalog %s: %s", p, err)
+	}
+	c, err := NewBuiltInCatalog(definitions)
+	if err != nil {
+		return nil, fmt.Errorf("error reading built-in policy catalog %s: %s", p, err)
+	}
+	return c, nil
+}
+
+// Lookup returns the named built-in policy definition, the name is not case sensitive.
+// The boolean is false if the policy definition is not in the catalog.
+func (c *BuiltInCatalog) Lookup(name string) (*armpolicy.Definition, bool) {
+	pd, ok := c.definitions[strings.ToLower(name)]
+	return pd, ok
+}
+
+// isDeprecated returns true if the policy definition is deprecated,
+// either in its metadata or by the `[Deprecated]` prefix of its display name
+func isDeprecated(pd *armpolicy.Definition) bool {
+	if pd.Properties == nil {
+		return false
+	}
+	if pd.Properties.DisplayName != nil && strings.HasPrefix(strings.ToLower(*pd.Properties.DisplayName), "[deprecated]") {
+		return true
+	}
+	metadata, ok := pd.Properties.Metadata.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	deprecated, _ := metadata["deprecated"].(bool)
+	return deprecated
+}
@@ -0,0 +1,67 @@
+package alzlib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Fingerprint returns the hex encoded SHA-256 of the canonical JSON of the supplied value.
+// The canonical JSON has sorted object keys and no insignificant whitespace,
+// so the fingerprint does not depend on map iteration order or on the formatting of the lib files.
+func Fingerprint(v interface{}) (string, error) {
+	data, err := canonicalJSON(v)
+	if err != nil {
+		return "", fmt.Errorf("error creating fingerprint: %s", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON returns the canonical JSON encoding of the supplied value.
+// The value is marshalled and then decoded into generic maps and slices,
+// so that custom marshallers that emit keys in a different order are normalised.
+// Numbers are kept as their original text, so that they are not changed by a round trip through float64.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// fingerprintContent returns the content of the archetype that is included in the library fingerprint.
+// The AlzLib is excluded, as it refers back to the archetype.
+// The content_hash of an archetype in the data sources is the fingerprint of its rendered state, not of this content.
+func (ad *ArchetypeDefinition) fingerprintContent() interface{} {
+	return map[string]interface{}{
+		"policy_assignments":     ad.PolicyAssignments,
+		"policy_definitions":     ad.PolicyDefinitions,
+		"policy_set_definitions": ad.PolicySetDefinitions,
+		"role_assignments":       ad.RoleAssignments,
+		"role_definitions":       ad.RoleDefinitions,
+	}
+}
+
+// Fingerprint returns the fingerprint of the whole library, covering all of the objects and archetypes
+func (az *AlzLib) Fingerprint() (string, error) {
+	archetypes := make(map[string]interface{}, len(az.Archetypes))
+	for name, ad := range az.Archetypes {
+		archetypes[name] = ad.fingerprintContent()
+	}
+	return Fingerprint(map[string]interface{}{
+		"archetypes":             archetypes,
+		"policy_assignments":     az.PolicyAssignments,
+		"policy_definitions":     az.PolicyDefinitions,
+		"policy_set_definitions": az.PolicySetDefinitions,
+		"role_definitions":       az.RoleDefinitions,
+	})
+}
@@ -0,0 +1,43 @@
+package alzlib
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestFingerprintCanonical tests that the fingerprint does not depend on key order or formatting
+func TestFingerprintCanonical(t *testing.T) {
+	a, err := Fingerprint(map[string]interface{}{"a": 1, "b": []string{"x", "y"}})
+	assert.NilError(t, err)
+	b, err := Fingerprint(struct {
+		B []string `json:"b"`
+		A int      `json:"a"`
+	}{B: []string{"x", "y"}, A: 1})
+	assert.NilError(t, err)
+	assert.Equal(t, a, b)
+	assert.Equal(t, len(a), 64)
+
+	c, err := Fingerprint(map[string]interface{}{"a": 1, "b": []string{"y", "x"}})
+	assert.NilError(t, err)
+	assert.Assert(t, a != c, "the order of list items is significant")
+}
+
+// TestLibraryFingerprint tests that the library fingerprint is stable and changes with the content
+func TestLibraryFingerprint(t *testing.T) {
+	az1, err := NewAlzLib("./testdata/layers/base")
+	assert.NilError(t, err)
+	az2, err := NewAlzLib("./testdata/layers/base")
+	assert.NilError(t, err)
+	az3, err := NewAlzLib("./testdata/layers/base", "./testdata/layers/override")
+	assert.NilError(t, err)
+
+	f1, err := az1.Fingerprint()
+	assert.NilError(t, err)
+	f2, err := az2.Fingerprint()
+	assert.NilError(t, err)
+	f3, err := az3.Fingerprint()
+	assert.NilError(t, err)
+	assert.Equal(t, f1, f2)
+	assert.Assert(t, f1 != f3)
+}
@@ -0,0 +1,148 @@
+package alzlib
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MaxManagementGroupDepth is the maximum number of levels of management groups that Azure supports
+// below the tenant root group
+const MaxManagementGroupDepth = 6
+
+// ManagementGroup is the input used to build a Hierarchy.
+// It assigns an archetype to a management group and places it in the tree.
+type ManagementGroup struct {
+	Id        string
+	ParentId  string
+	Archetype string
+}
+
+// HierarchyManagementGroup is a management group that has been placed into a Hierarchy
+type HierarchyManagementGroup struct {
+	ManagementGroup
+	// Depth is the level of the management group below the root parent, starting at 1
+	Depth int
+	// RootScopeId is the id of the top-level management group that this management group is beneath,
+	// it is used as the root_scope_id template variable
+	RootScopeId string
+}
+
+// Hierarchy is a validated tree of management groups, each with an archetype
+type Hierarchy struct {
+	AlzLib           *AlzLib
+	RootParentId     string
+	ManagementGroups map[string]*HierarchyManagementGroup
+}
+
+// HierarchyError is a problem with a management group in the hierarchy
+type HierarchyError struct {
+	// Id is the id of the management group that has the problem
+	Id      string
+	Message string
+}
+
+// Error implements the error interface
+func (e *HierarchyError) Error() string {
+	return e.Message
+}
+
+// hierarchyError returns a HierarchyError for the management group with the formatted message
+func hierarchyError(id string, format string, a ...interface{}) error {
+	return &HierarchyError{Id: id, Message: fmt.Sprintf(format, a...)}
+}
+
+// NewHierarchy validates the supplied management groups and creates a Hierarchy.
+// Management groups whose parent is the root parent id are the top-level management groups.
+// All problems are returned as HierarchyErrors, rather than just the first one.
+func (az *AlzLib) NewHierarchy(rootParentId string, mgs []ManagementGroup) (*Hierarchy, []error) {
+	errs := make([]error, 0)
+	h := &Hierarchy{
+		AlzLib:           az,
+		RootParentId:     rootParentId,
+		ManagementGroups: make(map[string]*HierarchyManagementGroup, len(mgs)),
+	}
+
+	for _, mg := range mgs {
+		if mg.Id == "" {
+			errs = append(errs, hierarchyError("", "management group id is empty"))
+			continue
+		}
+		if _, exists := h.ManagementGroups[mg.Id]; exists {
+			errs = append(errs, hierarchyError(mg.Id, "duplicate management group id: %s", mg.Id))
+			continue
+		}
+		if mg.Id == rootParentId {
+			errs = append(errs, hierarchyError(mg.Id, "management group %s has the same id as the root parent", mg.Id))
+			continue
+		}
+		if _, exists := az.Archetypes[mg.Archetype]; !exists {
+			errs = append(errs, hierarchyError(mg.Id, "management group %s has unknown archetype: %s", mg.Id, mg.Archetype))
+		}
+		h.ManagementGroups[mg.Id] = &HierarchyManagementGroup{ManagementGroup: mg}
+	}
+
+	// walk the tree upwards from each management group to the root parent,
+	// checking the parents exist and calculating the depth.
+	// Sort the ids so that the errors are deterministic.
+	for _, id := range h.sortedIds() {
+		mg := h.ManagementGroups[id]
+		visited := map[string]bool{id: true}
+		current := mg
+		depth := 1
+		for current.ParentId != rootParentId {
+			parent, exists := h.ManagementGroups[current.ParentId]
+			if !exists {
+				errs = append(errs, hierarchyError(current.Id, "management group %s has parent %s, which is neither a management group in the hierarchy nor the root parent %s", current.Id, current.ParentId, rootParentId))
+				break
+			}
+			if visited[parent.Id] {
+				errs = append(errs, hierarchyError(id, "management group %s is part of a cycle in the hierarchy", id))
+				break
+			}
+			visited[parent.Id] = true
+			current = parent
+			depth++
+		}
+		mg.Depth = depth
+		mg.RootScopeId = current.Id
+	}
+
+	// report the depth error once, for the deepest management groups
+	for _, id := range h.sortedIds() {
+		mg := h.ManagementGroups[id]
+		if mg.Depth == MaxManagementGroupDepth+1 {
+			errs = append(errs, hierarchyError(mg.Id, "management group %s is at depth %d, which exceeds the maximum depth of %d", mg.Id, mg.Depth, MaxManagementGroupDepth))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return h, nil
+}
+
+// TemplateVariables returns the template variables for the named management group,
+// with the root and current scopes set from its position in the hierarchy.
+// The supplied variables take precedence for the root scope, but not for the current scope.
+func (h *Hierarchy) TemplateVariables(id string, vars TemplateVariables) TemplateVariables {
+	mg := h.ManagementGroups[id]
+	base := TemplateVariables{
+		TemplateVariableRootScopeId: mg.RootScopeId,
+	}
+	return base.Merge(vars).WithScope(mg.Id)
+}
+
+// ResourceId returns the resource id of the named management group
+func (h *Hierarchy) ResourceId(id string) string {
+	return managementGroupResourceIdPrefix + id
+}
+
+// sortedIds returns the management group ids in the hierarchy in lexical order
+func (h *Hierarchy) sortedIds() []string {
+	ids := make([]string, 0, len(h.ManagementGroups))
+	for id := range h.ManagementGroups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,81 @@
+package alzlib
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// testHierarchyAlzLib returns an AlzLib with the supplied empty archetypes, for use in the hierarchy tests
+func testHierarchyAlzLib(archetypes ...string) *AlzLib {
+	az := &AlzLib{
+		Archetypes: make(map[string]*ArchetypeDefinition),
+	}
+	for _, a := range archetypes {
+		az.Archetypes[a] = newArchetypeDefinition(az)
+	}
+	return az
+}
+
+// TestNewHierarchy tests that a valid hierarchy has the correct depths and root scopes
+func TestNewHierarchy(t *testing.T) {
+	az := testHierarchyAlzLib("es_root", "es_landing_zones", "es_corp")
+	h, errs := az.NewHierarchy("tenant", []ManagementGroup{
+		{Id: "alz", ParentId: "tenant", Archetype: "es_root"},
+		{Id: "alz-landing-zones", ParentId: "alz", Archetype: "es_landing_zones"},
+		{Id: "alz-corp", ParentId: "alz-landing-zones", Archetype: "es_corp"},
+	})
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, h.ManagementGroups["alz"].Depth, 1)
+	assert.Equal(t, h.ManagementGroups["alz-corp"].Depth, 3)
+	assert.Equal(t, h.ManagementGroups["alz-corp"].RootScopeId, "alz")
+
+	vars := h.TemplateVariables("alz-corp", TemplateVariables{TemplateVariableDefaultLocation: "uksouth"})
+	assert.Equal(t, vars[TemplateVariableRootScopeId], "alz")
+	assert.Equal(t, vars[TemplateVariableCurrentScopeResourceId], "/providers/Microsoft.Management/managementGroups/alz-corp")
+	assert.Equal(t, vars[TemplateVariableDefaultLocation], "uksouth")
+}
+
+// TestNewHierarchyErrors tests that all of the problems with a hierarchy are reported
+func TestNewHierarchyErrors(t *testing.T) {
+	az := testHierarchyAlzLib("es_root")
+	_, errs := az.NewHierarchy("tenant", []ManagementGroup{
+		{Id: "alz", ParentId: "tenant", Archetype: "es_root"},
+		{Id: "alz-orphan", ParentId: "doesnotexist", Archetype: "es_root"},
+		{Id: "alz-unknown", ParentId: "alz", Archetype: "es_unknown"},
+		{Id: "alz-cycle1", ParentId: "alz-cycle2", Archetype: "es_root"},
+		{Id: "alz-cycle2", ParentId: "alz-cycle1", Archetype: "es_root"},
+	})
+	assert.DeepEqual(t, errs, []error{
+		&HierarchyError{Id: "alz-unknown", Message: "management group alz-unknown has unknown archetype: es_unknown"},
+		&HierarchyError{Id: "alz-cycle1", Message: "management group alz-cycle1 is part of a cycle in the hierarchy"},
+		&HierarchyError{Id: "alz-cycle2", Message: "management group alz-cycle2 is part of a cycle in the hierarchy"},
+		&HierarchyError{Id: "alz-orphan", Message: "management group alz-orphan has parent doesnotexist, which is neither a management group in the hierarchy nor the root parent tenant"},
+	})
+}
+
+// TestNewHierarchyMaxDepth tests that a hierarchy deeper than Azure supports is reported
+func TestNewHierarchyMaxDepth(t *testing.T) {
+	az := testHierarchyAlzLib("es_root")
+	mgs := make([]ManagementGroup, 0)
+	parent := "tenant"
+	for i := 1; i <= MaxManagementGroupDepth+2; i++ {
+		id := fmt.Sprintf("level%d", i)
+		mgs = append(mgs, ManagementGroup{Id: id, ParentId: parent, Archetype: "es_root"})
+		parent = id
+	}
+	_, errs := az.NewHierarchy("tenant", mgs)
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "management group level7 is at depth 7, which exceeds the maximum depth of 6")
+}
+
+// TestHierarchyResourceId tests the resource id of a management group
+func TestHierarchyResourceId(t *testing.T) {
+	az := testHierarchyAlzLib("es_root")
+	h, errs := az.NewHierarchy("tenant", []ManagementGroup{
+		{Id: "alz", ParentId: "tenant", Archetype: "es_root"},
+	})
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, h.ResourceId("alz"), "/providers/Microsoft.Management/managementGroups/alz")
+}
@@ -0,0 +1,206 @@
+package alzlib
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/bundle"
+)
+
+// HygieneCheck identifies a library hygiene check
+type HygieneCheck string
+
+// These are the library hygiene checks
+const (
+	// HygieneUnreferencedPolicyDefinition is a policy definition that is not in any archetype
+	HygieneUnreferencedPolicyDefinition HygieneCheck = "unreferenced_policy_definition"
+	// HygieneUnreferencedPolicySetDefinition is a policy set definition that is not in any archetype
+	HygieneUnreferencedPolicySetDefinition HygieneCheck = "unreferenced_policy_set_definition"
+	// HygieneUnreferencedPolicyAssignment is a policy assignment that is not in any archetype
+	HygieneUnreferencedPolicyAssignment HygieneCheck = "unreferenced_policy_assignment"
+	// HygieneUnreferencedRoleDefinition is a role definition that is not in any archetype
+	HygieneUnreferencedRoleDefinition HygieneCheck = "unreferenced_role_definition"
+	// HygieneUnknownPrefix is a JSON file that was ignored, as its name does not start with a lib file prefix
+	HygieneUnknownPrefix HygieneCheck = "unknown_prefix"
+	// HygieneEmptyArchetype is an archetype that has no policy or role objects
+	HygieneEmptyArchetype HygieneCheck = "empty_archetype"
+)
+
+// HygieneChecks are all of the hygiene checks, in the order that their findings are reported
+var HygieneChecks = []HygieneCheck{
+	HygieneUnreferencedPolicyDefinition,
+	HygieneUnreferencedPolicySetDefinition,
+	HygieneUnreferencedPolicyAssignment,
+	HygieneUnreferencedRoleDefinition,
+	HygieneUnknownPrefix,
+	HygieneEmptyArchetype,
+}
+
+// Severity is the severity level of a hygiene finding
+type Severity string
+
+// These are the severity levels of the hygiene findings
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	// SeverityOff disables the check
+	SeverityOff Severity = "off"
+)
+
+// DefaultHygieneSeverity is the severity of the checks that are not configured
+const DefaultHygieneSeverity = SeverityWarning
+
+// HygieneFinding is an object or file in the library that is not used
+type HygieneFinding struct {
+	Check    HygieneCheck
+	Severity Severity
+	// File is the path of the lib file, it is empty if the object was not read from a lib file
+	File string
+	// Name is the name of the object, or the path of the file for unknown_prefix findings
+	Name    string
+	Message string
+}
+
+// String returns the finding as a message, prefixed with the file if it is known and followed by the check
+func (f HygieneFinding) String() string {
+	if f.File == "" {
+		return fmt.Sprintf("%s (%s)", f.Message, f.Check)
+	}
+	return fmt.Sprintf("%s: %s (%s)", f.File, f.Message, f.Check)
+}
+
+// ParseHygieneCheck returns the named hygiene check, or an error if there is no check with the name
+func ParseHygieneCheck(s string) (HygieneCheck, error) {
+	for _, c := range HygieneChecks {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown hygiene check %s, it must be one of %s", s, joinHygieneChecks())
+}
+
+// ParseSeverity returns the named severity level, or an error if there is no level with the name
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(s); sev {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %s, it must be one of %s, %s, %s or %s", s, SeverityError, SeverityWarning, SeverityInfo, SeverityOff)
+}
+
+// Hygiene returns the objects in the library that are not in any archetype, the JSON files that were ignored
+// because their names do not start with a lib file prefix, and the archetypes that are empty.
+// The severities configure the severity of each check, checks that are not in the map have the DefaultHygieneSeverity
+// and checks with SeverityOff are not run.
+// The findings are sorted by check and then by name.
+func (az *AlzLib) Hygiene(severities map[HygieneCheck]Severity) []HygieneFinding {
+	var findings []HygieneFinding
+	for _, check := range HygieneChecks {
+		sev, ok := severities[check]
+		if !ok {
+			sev = DefaultHygieneSeverity
+		}
+		if sev == SeverityOff {
+			continue
+		}
+		for _, f := range az.hygieneCheck(check) {
+			f.Check, f.Severity = check, sev
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// hygieneCheck returns the findings of the supplied check, without the check and severity
+func (az *AlzLib) hygieneCheck(check HygieneCheck) []HygieneFinding {
+	switch check {
+	case HygieneUnreferencedPolicyDefinition:
+		return unreferenced(az, KindPolicyDefinition, az.PolicyDefinitions, func(ad *ArchetypeDefinition, name string) bool {
+			_, ok := ad.PolicyDefinitions[name]
+			return ok
+		})
+	case HygieneUnreferencedPolicySetDefinition:
+		return unreferenced(az, KindPolicySetDefinition, az.PolicySetDefinitions, func(ad *ArchetypeDefinition, name string) bool {
+			_, ok := ad.PolicySetDefinitions[name]
+			return ok
+		})
+	case HygieneUnreferencedPolicyAssignment:
+		return unreferenced(az, KindPolicyAssignment, az.PolicyAssignments, func(ad *ArchetypeDefinition, name string) bool {
+			_, ok := ad.PolicyAssignments[name]
+			return ok
+		})
+	case HygieneUnreferencedRoleDefinition:
+		return unreferenced(az, KindRoleDefinition, az.RoleDefinitions, func(ad *ArchetypeDefinition, name string) bool {
+			_, ok := ad.RoleDefinitions[name]
+			return ok
+		})
+	case HygieneUnknownPrefix:
+		findings := make([]HygieneFinding, 0, len(az.ignoredFiles))
+		for _, p := range az.ignoredFiles {
+			findings = append(findings, HygieneFinding{
+				File:    p,
+				Name:    p,
+				Message: "the file was ignored as its name does not start with a lib file prefix",
+			})
+		}
+		return findings
+	case HygieneEmptyArchetype:
+		var findings []HygieneFinding
+		for _, name := range sortedKeys(az.Archetypes) {
+			ad := az.Archetypes[name]
+			if len(ad.PolicyDefinitions)+len(ad.PolicySetDefinitions)+len(ad.PolicyAssignments)+len(ad.RoleDefinitions)+len(ad.RoleAssignments) > 0 {
+				continue
+			}
+			findings = append(findings, HygieneFinding{
+				File:    az.SourceFile(KindArchetypeDefinition, name),
+				Name:    name,
+				Message: fmt.Sprintf("archetype %s is empty", name),
+			})
+		}
+		return findings
+	}
+	return nil
+}
+
+// unreferenced returns a finding for each of the objects that is not in any archetype
+func unreferenced[T any](az *AlzLib, kind ObjectKind, objects map[string]T, inArchetype func(*ArchetypeDefinition, string) bool) []HygieneFinding {
+	var findings []HygieneFinding
+	for _, name := range sortedKeys(objects) {
+		referenced := false
+		for _, ad := range az.Archetypes {
+			if inArchetype(ad, name) {
+				referenced = true
+				break
+			}
+		}
+		if referenced {
+			continue
+		}
+		findings = append(findings, HygieneFinding{
+			File:    az.SourceFile(kind, name),
+			Name:    name,
+			Message: fmt.Sprintf("%s %s is not referenced by any archetype", strings.ReplaceAll(string(kind), "_", " "), name),
+		})
+	}
+	return findings
+}
+
+// isIgnoredLibFile returns true if the file with the supplied lower case base name is a JSON file
+// that looks like it was meant to be a lib file, i.e. it is not hidden or a bundle file
+func isIgnoredLibFile(name string) bool {
+	if filepath.Ext(name) != ".json" || strings.HasPrefix(name, ".") {
+		return false
+	}
+	return name != bundle.ManifestFileName
+}
+
+// joinHygieneChecks returns the names of the hygiene checks, used in error messages
+func joinHygieneChecks() string {
+	names := make([]string, len(HygieneChecks))
+	for i, c := range HygieneChecks {
+		names[i] = string(c)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,58 @@
+package alzlib
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestHygiene tests that the unreferenced objects, ignored files and empty archetypes are reported,
+// and that hidden files and files that are not JSON are not
+func TestHygiene(t *testing.T) {
+	az, err := NewAlzLib("testdata/hygiene")
+	assert.NilError(t, err)
+
+	dir := "testdata/hygiene/"
+	assert.DeepEqual(t, az.Hygiene(nil), []HygieneFinding{
+		{Check: HygieneUnreferencedPolicyDefinition, Severity: SeverityWarning, File: dir + "policy_definition_unused.json", Name: "unused", Message: "policy definition unused is not referenced by any archetype"},
+		{Check: HygieneUnreferencedPolicySetDefinition, Severity: SeverityWarning, File: dir + "policy_set_definition_unused.json", Name: "unused-set", Message: "policy set definition unused-set is not referenced by any archetype"},
+		{Check: HygieneUnreferencedPolicyAssignment, Severity: SeverityWarning, File: dir + "policy_assignment_unused.json", Name: "unused-assignment", Message: "policy assignment unused-assignment is not referenced by any archetype"},
+		{Check: HygieneUnreferencedRoleDefinition, Severity: SeverityWarning, File: dir + "role_definition_unused.json", Name: "unused-role", Message: "role definition unused-role is not referenced by any archetype"},
+		{Check: HygieneUnknownPrefix, Severity: SeverityWarning, File: dir + "policy_defintion_typo.json", Name: dir + "policy_defintion_typo.json", Message: "the file was ignored as its name does not start with a lib file prefix"},
+		{Check: HygieneEmptyArchetype, Severity: SeverityWarning, File: dir + "archetype_definition_empty.json", Name: "empty", Message: "archetype empty is empty"},
+	})
+}
+
+// TestHygieneSeverities tests that the configured severities are used and that checks can be turned off
+func TestHygieneSeverities(t *testing.T) {
+	az, err := NewAlzLib("testdata/hygiene")
+	assert.NilError(t, err)
+
+	findings := az.Hygiene(map[HygieneCheck]Severity{
+		HygieneUnreferencedPolicyDefinition:    SeverityOff,
+		HygieneUnreferencedPolicySetDefinition: SeverityOff,
+		HygieneUnreferencedPolicyAssignment:    SeverityOff,
+		HygieneUnreferencedRoleDefinition:      SeverityInfo,
+		HygieneUnknownPrefix:                   SeverityError,
+	})
+	assert.Equal(t, len(findings), 3)
+	assert.Equal(t, findings[0].Severity, SeverityInfo)
+	assert.Equal(t, findings[1].Severity, SeverityError)
+	assert.Equal(t, findings[2].Severity, DefaultHygieneSeverity)
+	assert.Equal(t, findings[2].String(), "testdata/hygiene/archetype_definition_empty.json: archetype empty is empty (empty_archetype)")
+}
+
+// TestParseHygieneCheckAndSeverity tests the parsing of the configured checks and severities
+func TestParseHygieneCheckAndSeverity(t *testing.T) {
+	c, err := ParseHygieneCheck("empty_archetype")
+	assert.NilError(t, err)
+	assert.Equal(t, c, HygieneEmptyArchetype)
+	_, err = ParseHygieneCheck("empty")
+	assert.ErrorContains(t, err, "unknown hygiene check empty, it must be one of unreferenced_policy_definition,")
+
+	s, err := ParseSeverity("off")
+	assert.NilError(t, err)
+	assert.Equal(t, s, SeverityOff)
+	_, err = ParseSeverity("fatal")
+	assert.ErrorContains(t, err, "unknown severity fatal, it must be one of error, warning, info or off")
+}
@@ -0,0 +1,111 @@
+package alzlib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// policyDefinitionIdSegment is the part of a policy definition resource id that precedes the name
+const policyDefinitionIdSegment = "/providers/microsoft.authorization/policydefinitions/"
+
+// MemberStatus is the result of resolving a policy set definition member
+type MemberStatus string
+
+// These are the statuses of the policy set definition members
+const (
+	// MemberStatusLibrary is a custom policy definition that is in the library
+	MemberStatusLibrary MemberStatus = "library"
+	// MemberStatusBuiltIn is a built-in policy definition that is in the built-in catalog
+	MemberStatusBuiltIn MemberStatus = "built_in"
+	// MemberStatusDeprecated is a policy definition that was found, but is deprecated
+	MemberStatusDeprecated MemberStatus = "deprecated"
+	// MemberStatusMissing is a policy definition that is not in the library or the built-in catalog
+	MemberStatusMissing MemberStatus = "missing"
+	// MemberStatusUnchecked is a built-in policy definition that was not checked, as there is no built-in catalog
+	MemberStatusUnchecked MemberStatus = "unchecked"
+)
+
+// PolicySetMember is a member of a policy set definition, resolved against the library and the built-in catalog
+type PolicySetMember struct {
+	// PolicyDefinitionId is the policy definition id of the member, as it is in the lib file
+	PolicyDefinitionId string
+	// PolicyDefinitionName is the name of the policy definition, the last segment of its id
+	PolicyDefinitionName string
+	// BuiltIn is true if the id is of a built-in policy definition
+	BuiltIn bool
+	// Status is the result of resolving the member
+	Status MemberStatus
+}
+
+// PolicySetDefinitionMembers returns the members of the named policy set definition, in the order that they are declared,
+// with the result of resolving each of them. It returns nil if the policy set definition is not known.
+func (az *AlzLib) PolicySetDefinitionMembers(name string) []PolicySetMember {
+	members, ok := az.members[name]
+	if !ok {
+		return nil
+	}
+	return append([]PolicySetMember{}, members...)
+}
+
+// resolvePolicySetDefinitionMembers resolves the members of every policy set definition.
+// Custom policy definitions are resolved against the library and built-in policy definitions against the catalog,
+// which can be nil, in which case they are not checked.
+// Missing members are returned as findings, and deprecated members as warnings.
+func (az *AlzLib) resolvePolicySetDefinitionMembers(catalog *BuiltInCatalog) []Finding {
+	var findings []Finding
+	az.members = make(map[string][]PolicySetMember, len(az.PolicySetDefinitions))
+	for _, name := range sortedKeys(az.PolicySetDefinitions) {
+		psd := az.PolicySetDefinitions[name]
+		if psd.Properties == nil {
+			az.members[name] = []PolicySetMember{}
+			continue
+		}
+		file := az.SourceFile(KindPolicySetDefinition, name)
+		members := make([]PolicySetMember, 0, len(psd.Properties.PolicyDefinitions))
+		for i, ref := range psd.Properties.PolicyDefinitions {
+			pointer := jsonPointer("properties", "policyDefinitions", i, "policyDefinitionId")
+			m := PolicySetMember{Status: MemberStatusMissing}
+			if ref != nil && ref.PolicyDefinitionID != nil {
+				m.PolicyDefinitionId = *ref.PolicyDefinitionID
+			}
+
+			idx := strings.Index(strings.ToLower(m.PolicyDefinitionId), policyDefinitionIdSegment)
+			if idx < 0 {
+				findings = append(findings, Finding{File: file, Pointer: pointer, Message: fmt.Sprintf("policy set definition %s member %s is not a policy definition id", name, m.PolicyDefinitionId)})
+				members = append(members, m)
+				continue
+			}
+			m.PolicyDefinitionName = m.PolicyDefinitionId[idx+len(policyDefinitionIdSegment):]
+			// built-in policy definitions are at the tenant root, custom ones are under a scope
+			m.BuiltIn = idx == 0
+
+			switch pd, ok := az.PolicyDefinitions[m.PolicyDefinitionName]; {
+			case !m.BuiltIn && !ok:
+				findings = append(findings, Finding{File: file, Pointer: pointer, Message: fmt.Sprintf("policy set definition %s member %s is not a library policy definition", name, m.PolicyDefinitionName)})
+			case !m.BuiltIn:
+				m.Status = MemberStatusLibrary
+				if isDeprecated(pd) {
+					m.Status = MemberStatusDeprecated
+				}
+			case catalog == nil:
+				m.Status = MemberStatusUnchecked
+			default:
+				pd, ok := catalog.Lookup(m.PolicyDefinitionName)
+				if !ok {
+					findings = append(findings, Finding{File: file, Pointer: pointer, Message: fmt.Sprintf("policy set definition %s member %s is not in the built-in policy catalog", name, m.PolicyDefinitionName)})
+					break
+				}
+				m.Status = MemberStatusBuiltIn
+				if isDeprecated(pd) {
+					m.Status = MemberStatusDeprecated
+				}
+			}
+			if m.Status == MemberStatusDeprecated {
+				findings = append(findings, Finding{File: file, Pointer: pointer, Message: fmt.Sprintf("policy set definition %s member %s is deprecated", name, m.PolicyDefinitionName), Warning: true})
+			}
+			members = append(members, m)
+		}
+		az.members[name] = members
+	}
+	return findings
+}
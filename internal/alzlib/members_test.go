@@ -0,0 +1,74 @@
+package alzlib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestPolicySetDefinitionMembers tests that the members are resolved against the library and the built-in catalog
+func TestPolicySetDefinitionMembers(t *testing.T) {
+	catalog, err := ReadBuiltInCatalog("./testdata/builtin_policy_catalog.json")
+	assert.NilError(t, err)
+	l, err := DirLayer("./testdata/policysetmembers")
+	assert.NilError(t, err)
+	az, findings, err := ValidateLayers(catalog, l)
+	assert.NilError(t, err)
+
+	statuses := []MemberStatus{}
+	for _, m := range az.PolicySetDefinitionMembers("test") {
+		statuses = append(statuses, m.Status)
+	}
+	assert.DeepEqual(t, statuses, []MemberStatus{
+		MemberStatusLibrary,
+		MemberStatusDeprecated,
+		MemberStatusMissing,
+		MemberStatusBuiltIn,
+		MemberStatusDeprecated,
+		MemberStatusMissing,
+	})
+
+	file := "testdata/policysetmembers/policy_set_definition_test.json"
+	assert.DeepEqual(t, findings, []Finding{
+		{File: file, Pointer: "/properties/policyDefinitions/1/policyDefinitionId", Message: "policy set definition test member old-custom is deprecated", Warning: true},
+		{File: file, Pointer: "/properties/policyDefinitions/2/policyDefinitionId", Message: "policy set definition test member missing-custom is not a library policy definition"},
+		{File: file, Pointer: "/properties/policyDefinitions/4/policyDefinitionId", Message: "policy set definition test member 00000000-0000-0000-0000-000000000002 is deprecated", Warning: true},
+		{File: file, Pointer: "/properties/policyDefinitions/5/policyDefinitionId", Message: "policy set definition test member 00000000-0000-0000-0000-000000000003 is not in the built-in policy catalog"},
+	})
+}
+
+// TestPolicySetDefinitionMembersNoCatalog tests that built-in members are not checked without a catalog,
+// and that only the missing library member is an error
+func TestPolicySetDefinitionMembersNoCatalog(t *testing.T) {
+	l, err := DirLayer("./testdata/policysetmembers")
+	assert.NilError(t, err)
+	az, findings, err := ValidateLayers(nil, l)
+	assert.NilError(t, err)
+	members := az.PolicySetDefinitionMembers("test")
+	assert.Equal(t, members[3].Status, MemberStatusUnchecked)
+	assert.Assert(t, members[3].BuiltIn)
+	assert.Equal(t, members[3].PolicyDefinitionName, "00000000-0000-0000-0000-000000000001")
+	assert.Equal(t, len(findings), 2)
+
+	_, err = NewAlzLibFromLayers(l)
+	verr, ok := err.(*ValidationError)
+	assert.Assert(t, ok, err)
+	assert.Equal(t, len(verr.Findings), 1)
+	assert.ErrorContains(t, err, "member missing-custom is not a library policy definition")
+}
+
+// TestReadBuiltInCatalogListResponse tests that the catalog can be read from a REST API list response
+func TestReadBuiltInCatalogListResponse(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "catalog.json")
+	assert.NilError(t, os.WriteFile(p, []byte(`{"value": [{"name": "ABC"}]}`), 0o644))
+	c, err := ReadBuiltInCatalog(p)
+	assert.NilError(t, err)
+	_, ok := c.Lookup("abc")
+	assert.Assert(t, ok)
+
+	assert.NilError(t, os.WriteFile(p, []byte(`[{"properties": {}}]`), 0o644))
+	_, err = ReadBuiltInCatalog(p)
+	assert.ErrorContains(t, err, "policy definition at index 0 has no name")
+}
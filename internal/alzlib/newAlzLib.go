@@ -0,0 +1,305 @@
+package alzlib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+)
+
+// These are the file prefixes for the resource types
+const archetypeDefinitionPrefix = "archetype_definition_"
+const archetypeExclusionPrefix = "archetype_exclusion_"
+const archetypeExtensionPrefix = "archetype_extension_"
+const policyAssignmentPrefix = "policy_assignment_"
+const policyDefinitionPrefix = "policy_definition_"
+const policySetDefinitionPrefix = "policy_set_definition_"
+const roleDefinitionPrefix = "role_definition_"
+
+// LibLayer is a layer of the library, the lib files are read from its file system
+type LibLayer struct {
+	// Name identifies the layer, e.g. the directory path.
+	// It is prepended to the paths of the lib files in the layer when they are reported.
+	Name string
+	// FS is the file system containing the lib files
+	FS fs.FS
+	// Walk controls which files in the file system are read
+	Walk WalkOptions
+	// Cache, if set, is used to avoid reading and parsing the lib files that have not changed since they were last parsed.
+	// It is only used by the layers returned by DirLayer, as the files in embedded file systems and archives do not have
+	// modification times that change with their content.
+	// It must not be set if the SHA-256 of the processed files is used to verify them.
+	Cache *ParseCache
+	// dir is the absolute path of the directory of a layer returned by DirLayer, which identifies its files in the parse cache
+	dir string
+}
+
+// DirLayer returns a LibLayer that reads the lib files from the supplied directory
+func DirLayer(dir string) (LibLayer, error) {
+	if err := checkDirExists(dir); err != nil {
+		return LibLayer{}, err
+	}
+	l := LibLayer{Name: dir, FS: os.DirFS(dir)}
+	// without an absolute path the files cannot be identified in the parse cache, so they are not cached
+	if abs, err := filepath.Abs(dir); err == nil {
+		l.dir = abs
+	}
+	return l, nil
+}
+
+// NewAlzLib returns a new instance of the alzlib library using the supplied directories.
+// The directories are layers that are processed in order, see NewAlzLibFromLayers.
+// A directory can also be a .zip or .tar.gz archive of lib files, see ArchiveLayer.
+func NewAlzLib(dirs ...string) (*AlzLib, error) {
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no lib directories supplied")
+	}
+	layers := make([]LibLayer, len(dirs))
+	for i, dir := range dirs {
+		l, err := NewLayer(dir)
+		if err != nil {
+			return nil, err
+		}
+		layers[i] = l
+	}
+	return NewAlzLibFromLayers(layers...)
+}
+
+// NewAlzLibFromLayers returns a new instance of the alzlib library using the supplied layers.
+// The layers are processed in order, objects in a later layer override
+// objects with the same name in an earlier layer.
+// Archetype extensions and exclusions from all layers are applied, in layer order.
+// If the library has problems, the error is a ValidationError that lists all of them, warnings are ignored.
+// The built-in members of the policy set definitions are not checked, use ValidateLayers with a catalog to check them.
+func NewAlzLibFromLayers(layers ...LibLayer) (*AlzLib, error) {
+	az, findings, err := ValidateLayers(nil, layers...)
+	if err != nil {
+		return nil, err
+	}
+	if err := findingsError(onlyErrors(findings)); err != nil {
+		return nil, err
+	}
+	return az, nil
+}
+
+// ValidateLayers loads the library from the supplied layers in the same way as NewAlzLibFromLayers,
+// but it does not stop at the first problem.
+// It returns the library, which is incomplete if there are any findings, and every problem that was found.
+// Unparseable files and objects with unknown references are left out of the library.
+// The members of the policy set definitions are resolved against the library and the built-in catalog,
+// which can be nil, in which case the built-in members are not checked.
+func ValidateLayers(catalog *BuiltInCatalog, layers ...LibLayer) (*AlzLib, []Finding, error) {
+	if len(layers) == 0 {
+		return nil, nil, fmt.Errorf("no lib directories supplied")
+	}
+
+	az := &AlzLib{
+		Archetypes:              make(map[string]*ArchetypeDefinition),
+		PolicyAssignments:       make(map[string]*armpolicy.Assignment),
+		PolicyDefinitions:       make(map[string]*armpolicy.Definition),
+		PolicySetDefinitions:    make(map[string]*armpolicy.SetDefinition),
+		RoleDefinitions:         make(map[string]*RoleDefinition),
+		libArchetypeDefinitions: make([]*LibArchetypeDefinition, 0),
+		sources:                 make(map[objectKey]ObjectSource),
+		layers:                  make([]string, len(layers)),
+		catalog:                 catalog,
+	}
+	for i, l := range layers {
+		az.layers[i] = l.Name
+	}
+
+	// Walk each layer in turn to find the lib files, then parse them concurrently and add them in order,
+	// continuing after any problems
+	var findings []Finding
+	var files []*libFile
+	for i, l := range layers {
+		walkFindings, err := walkLayer(l, func(p string) {
+			path := filepath.Join(l.Name, filepath.FromSlash(p))
+			kind := libFileKind(p)
+			if kind == "" {
+				// JSON files are recorded as they may have a misspelt prefix
+				if isIgnoredLibFile(strings.ToLower(filepath.Base(path))) {
+					az.ignoredFiles = append(az.ignoredFiles, path)
+				}
+				l.Walk.log(p, "ignored, not a lib file")
+				return
+			}
+			l.Walk.log(p, string(kind))
+			files = append(files, &libFile{layer: i, name: p, path: path, kind: kind})
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		findings = append(findings, walkFindings...)
+	}
+
+	parseLibFiles(layers, files)
+	for _, f := range files {
+		if err := az.addLibFile(f); err != nil {
+			findings = append(findings, errorFindings(err, "", "")...)
+		}
+	}
+
+	findings = append(findings, az.resolvePolicySetDefinitionMembers(catalog)...)
+	findings = append(findings, az.checkPolicyAssignments()...)
+
+	if err := az.generateArchetypes(); err != nil {
+		findings = append(findings, errorFindings(err, "", "")...)
+	}
+
+	return az, findings, nil
+}
+
+// checkDirExists checks if the supplied directory exists and is a directory
+func checkDirExists(dir string) error {
+	fs, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("the supplied lib directory does not exist: %s. %s", dir, err)
+	}
+	// The error is nil, so let's check if it's actually a directory
+	if !fs.IsDir() {
+		return fmt.Errorf("%s is not a directory and it should be", dir)
+	}
+	return nil
+}
+
+// libFileKind returns the kind of lib file with the supplied name, based on its prefix.
+// It returns an empty string if the file is not a lib file.
+func libFileKind(name string) ObjectKind {
+	n := strings.ToLower(path.Base(name))
+	for _, k := range libFilePrefixes {
+		if strings.HasPrefix(n, k.prefix) {
+			return k.kind
+		}
+	}
+	return ""
+}
+
+// IsLibFile returns true if the file with the supplied path is a lib file, based on the prefix of its name
+func IsLibFile(p string) bool {
+	return libFileKind(p) != ""
+}
+
+// libFilePrefixes are the file name prefixes of each kind of lib file
+var libFilePrefixes = []struct {
+	prefix string
+	kind   ObjectKind
+}{
+	{policyDefinitionPrefix, KindPolicyDefinition},
+	{policySetDefinitionPrefix, KindPolicySetDefinition},
+	{policyAssignmentPrefix, KindPolicyAssignment},
+	{roleDefinitionPrefix, KindRoleDefinition},
+	{archetypeDefinitionPrefix, KindArchetypeDefinition},
+	{archetypeExclusionPrefix, KindArchetypeExclusion},
+	{archetypeExtensionPrefix, KindArchetypeExtension},
+}
+
+// libFile is a lib file that is being loaded
+type libFile struct {
+	// layer is the index of the library layer
+	layer int
+	// name is the path of the file within the layer file system and path is used when reporting the file
+	name string
+	path string
+	kind ObjectKind
+	// sha256 is the hex encoded SHA-256 of the file contents, it is empty if the file could not be read
+	sha256 string
+	// obj is the parsed object, or err is the problem reading or parsing the file
+	obj interface{}
+	err error
+}
+
+// parseLibFiles reads and parses the lib files, using a bounded pool of workers.
+// The results are stored in the files, so they can be added to the library in order.
+func parseLibFiles(layers []LibLayer, files []*libFile) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(files) {
+		workers = len(files)
+	}
+	jobs := make(chan *libFile)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				f.parse(layers[f.layer])
+			}
+		}()
+	}
+	for _, f := range files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// parse reads and parses the lib file from the layer.
+// If the layer is a directory with a parse cache and the file has not changed since it was cached, the file is not read.
+func (f *libFile) parse(l LibLayer) {
+	p := libFileProcessors[f.kind]
+	entry, cache := l.Cache.stat(l, f)
+	if cache {
+		if obj, sum, ok := l.Cache.lookup(entry, p); ok {
+			f.obj, f.sha256 = obj, sum
+			return
+		}
+	}
+
+	data, err := fs.ReadFile(l.FS, f.name)
+	if err != nil {
+		f.err = err
+		return
+	}
+	sum := sha256.Sum256(data)
+	f.sha256 = hex.EncodeToString(sum[:])
+
+	if f.obj, f.err = p.parse(data); f.err == nil && cache {
+		l.Cache.store(entry, f)
+	}
+}
+
+// addLibFile records the parsed lib file in the processed files, and adds its object to the AlzLib.
+// Problems reading or parsing the file are returned as findings in the file.
+func (az *AlzLib) addLibFile(f *libFile) error {
+	az.layer = f.layer
+	// files that could not be read are not recorded
+	if f.sha256 == "" {
+		return findingsError(errorFindings(f.err, f.path, ""))
+	}
+	az.files = append(az.files, LibFile{
+		Layer:  f.layer,
+		Path:   f.name,
+		Kind:   f.kind,
+		Sha256: f.sha256,
+	})
+	err := f.err
+	if err == nil {
+		err = libFileProcessors[f.kind].add(az, f.path, f.obj)
+	}
+	// If there's an error, return it as a finding in the file
+	if err != nil {
+		return findingsError(errorFindings(err, f.path, ""))
+	}
+	return nil
+}
+
+// setFileObjectName records the name of the object declared in the lib file that is currently being processed
+func (az *AlzLib) setFileObjectName(name string) {
+	if len(az.files) > 0 {
+		az.files[len(az.files)-1].Name = name
+	}
+}
+
+// Files returns the lib files that have been processed, in the order that they were processed
+func (az *AlzLib) Files() []LibFile {
+	return append([]LibFile{}, az.files...)
+}
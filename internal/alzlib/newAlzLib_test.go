@@ -0,0 +1,170 @@
+package alzlib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// testLibDir is the reference library that is embedded in the provider, which is also used as test data.
+// The library package cannot be imported here, as it imports this package.
+const testLibDir = "../library/lib"
+
+// Test_NewAlzLib tests the valid creation of a new AlzLib from a valid source directory
+func Test_NewAlzLib(t *testing.T) {
+	az, err := NewAlzLib(testLibDir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(az.PolicyAssignments), 35)
+	assert.Equal(t, len(az.PolicyDefinitions), 104)
+	assert.Equal(t, len(az.PolicySetDefinitions), 7)
+	assert.Equal(t, len(az.RoleDefinitions), 5)
+	assert.Equal(t, len(az.Archetypes["es_root"].RoleDefinitions), 5)
+	assert.Equal(t, len(az.libArchetypeDefinitions), 12)
+}
+
+// Test_NewAlzLib_noDir tests the creation of a new AlzLib when supplied with a path
+// that does not exist.
+// The error details are checked for the expected error message.
+func Test_NewAlzLib_noDir(t *testing.T) {
+	_, err := NewAlzLib("./testdata/doesnotexist")
+	assert.ErrorContains(t, err, "the supplied lib directory does not exist")
+}
+
+// Test_NewAlzLib_notADir tests the creation of a new AlzLib when supplied with a valid
+// path that is not a directory.
+// The error details are checked for the expected error message.
+func Test_NewAlzLib_notADir(t *testing.T) {
+	_, err := NewAlzLib("./testdata/notadirectory")
+	assert.ErrorContains(t, err, "is not a directory and it should be")
+}
+
+// Benchmark_NewAlzLib benchmarks the creation of a new AlzLib based on the test data set
+func Benchmark_NewAlzLib(b *testing.B) {
+	_, e := NewAlzLib(testLibDir)
+	if e != nil {
+		b.Error(e)
+	}
+}
+
+// Test_NewAlzLib tests the valid creation of a new AlzLib from a valid source directory
+func Test_NewAlzLibDuplicateArchetypeDefinition(t *testing.T) {
+	_, err := NewAlzLib("./testdata/badlib-duplicatearchetypedef")
+	assert.ErrorContains(t, err, "duplicate archetype id: duplicate")
+}
+
+// Test_NewAlzLibLayers tests that objects in a later layer override those in an earlier layer,
+// that archetype extensions in a later layer are applied, and that the source layer is recorded
+func Test_NewAlzLibLayers(t *testing.T) {
+	az, err := NewAlzLib("./testdata/layers/base", "./testdata/layers/override")
+	assert.NilError(t, err)
+	assert.Equal(t, len(az.PolicyDefinitions), 2)
+	assert.Equal(t, *az.PolicyDefinitions["test-policy"].Properties.DisplayName, "override layer")
+	assert.Equal(t, *az.Archetypes["test"].PolicyDefinitions["test-policy"].Properties.DisplayName, "override layer")
+	assert.Equal(t, len(az.Archetypes["test"].PolicyDefinitions), 2)
+
+	src, ok := az.Source(KindPolicyDefinition, "test-policy")
+	assert.Assert(t, ok)
+	assert.Equal(t, src.Layer, 1)
+	assert.Equal(t, src.LayerPath, "./testdata/layers/override")
+	assert.Equal(t, src.Replaces, "testdata/layers/base/policy_definition_test_policy.json")
+	src, ok = az.Source(KindPolicyDefinition, "test-policy2")
+	assert.Assert(t, ok)
+	assert.Equal(t, src.Layer, 0)
+	src, ok = az.Source(KindArchetypeDefinition, "test")
+	assert.Assert(t, ok)
+	assert.Equal(t, src.LayerPath, "./testdata/layers/base")
+	assert.DeepEqual(t, az.Layers(), []string{"./testdata/layers/base", "./testdata/layers/override"})
+}
+
+// Test_NewAlzLibLayersDuplicate tests that an object declared twice in the same layer is an error
+func Test_NewAlzLibLayersDuplicate(t *testing.T) {
+	_, err := NewAlzLib("./testdata/layers/base", "./testdata/layers/duplicate")
+	assert.ErrorContains(t, err, "duplicate policy definition name: test-policy, declared in files testdata/layers/duplicate/policy_definition_test_policy_a.json and testdata/layers/duplicate/policy_definition_test_policy_b.json")
+}
+
+// Test_NewAlzLibDuplicateNames tests that every kind of object declared twice in the same layer is an error naming both files
+func Test_NewAlzLibDuplicateNames(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "policy set definition",
+			files: map[string]string{
+				"policy_set_definition_a.json": `{"name": "test"}`,
+				"policy_set_definition_b.json": `{"name": "test"}`,
+			},
+			err: "duplicate policy set definition name: test, declared in files %[1]s/policy_set_definition_a.json and %[1]s/policy_set_definition_b.json",
+		},
+		{
+			name: "policy assignment",
+			files: map[string]string{
+				"policy_assignment_a.json": `{"name": "test"}`,
+				"policy_assignment_b.json": `{"name": "test"}`,
+			},
+			err: "duplicate policy assignment name: test, declared in files %[1]s/policy_assignment_a.json and %[1]s/policy_assignment_b.json",
+		},
+		{
+			name: "role definition name",
+			files: map[string]string{
+				"role_definition_a.json": `{"name": "00000000-0000-0000-0000-00000000000a", "properties": {"roleName": "test"}}`,
+				"role_definition_b.json": `{"name": "00000000-0000-0000-0000-00000000000b", "properties": {"roleName": "test"}}`,
+			},
+			err: "duplicate role definition name: test, declared in files %[1]s/role_definition_a.json and %[1]s/role_definition_b.json",
+		},
+		{
+			name: "role definition id",
+			files: map[string]string{
+				"role_definition_a.json": `{"name": "00000000-0000-0000-0000-00000000000a", "properties": {"roleName": "test a"}}`,
+				"role_definition_b.json": `{"name": "00000000-0000-0000-0000-00000000000a", "properties": {"roleName": "test b"}}`,
+			},
+			err: "duplicate role definition id: 00000000-0000-0000-0000-00000000000a, declared in files %[1]s/role_definition_a.json and %[1]s/role_definition_b.json",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeLibFiles(t, dir, c.files)
+			_, err := NewAlzLib(dir)
+			assert.ErrorContains(t, err, fmt.Sprintf(c.err, dir))
+		})
+	}
+}
+
+// Test_NewAlzLibRoleDefinitionReplacedById tests that a role definition in a later layer replaces one with the same id,
+// even if it has a different role name
+func Test_NewAlzLibRoleDefinitionReplacedById(t *testing.T) {
+	base, override := t.TempDir(), t.TempDir()
+	writeLibFiles(t, base, map[string]string{
+		"role_definition_test.json": `{"name": "00000000-0000-0000-0000-00000000000a", "properties": {"roleName": "old name"}}`,
+	})
+	writeLibFiles(t, override, map[string]string{
+		"role_definition_test.json": `{"name": "00000000-0000-0000-0000-00000000000a", "properties": {"roleName": "new name"}}`,
+	})
+	az, err := NewAlzLib(base, override)
+	assert.NilError(t, err)
+	assert.Equal(t, len(az.RoleDefinitions), 1)
+	src, ok := az.Source(KindRoleDefinition, "new name")
+	assert.Assert(t, ok)
+	assert.Equal(t, src.Replaces, filepath.Join(base, "role_definition_test.json"))
+	_, ok = az.Source(KindRoleDefinition, "old name")
+	assert.Assert(t, !ok)
+}
+
+// writeLibFiles writes the supplied lib files, keyed by file name, to the directory
+func writeLibFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+}
+
+// Test_NewAlzLibNoDirs tests that at least one directory must be supplied
+func Test_NewAlzLibNoDirs(t *testing.T) {
+	_, err := NewAlzLib()
+	assert.ErrorContains(t, err, "no lib directories supplied")
+}
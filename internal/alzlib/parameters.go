@@ -0,0 +1,238 @@
+package alzlib
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+)
+
+// policySetDefinitionIdSegment is the part of a policy set definition resource id that precedes the name
+const policySetDefinitionIdSegment = "/providers/microsoft.authorization/policysetdefinitions/"
+
+// parameterSchema returns the parameter definitions of the policy definition or policy set definition with the supplied id.
+// Custom definitions are looked up in the library and built-in policy definitions in the built-in catalog.
+// The boolean is false if the definition cannot be checked, e.g. a built-in policy set definition,
+// and an error is returned if it is a custom definition that is not in the library.
+func (az *AlzLib) parameterSchema(id string) (map[string]*armpolicy.ParameterDefinitionsValue, bool, error) {
+	lower := strings.ToLower(id)
+	if idx := strings.Index(lower, policyDefinitionIdSegment); idx >= 0 {
+		name := id[idx+len(policyDefinitionIdSegment):]
+		var pd *armpolicy.Definition
+		var ok bool
+		switch {
+		case idx > 0:
+			if pd, ok = az.PolicyDefinitions[name]; !ok {
+				return nil, false, fmt.Errorf("policy definition %s is not in the library", name)
+			}
+		case az.catalog != nil:
+			if pd, ok = az.catalog.Lookup(name); !ok {
+				return nil, false, fmt.Errorf("policy definition %s is not in the built-in policy catalog", name)
+			}
+		default:
+			return nil, false, nil
+		}
+		if pd.Properties == nil {
+			return nil, true, nil
+		}
+		return pd.Properties.Parameters, true, nil
+	}
+
+	if idx := strings.Index(lower, policySetDefinitionIdSegment); idx > 0 {
+		name := id[idx+len(policySetDefinitionIdSegment):]
+		psd, ok := az.PolicySetDefinitions[name]
+		if !ok {
+			return nil, false, fmt.Errorf("policy set definition %s is not in the library", name)
+		}
+		if psd.Properties == nil {
+			return nil, true, nil
+		}
+		return psd.Properties.Parameters, true, nil
+	}
+	return nil, false, nil
+}
+
+// checkPolicyAssignments checks the parameters of each library policy assignment against the parameter schema of its definition.
+// Parameter values must have the declared type and be one of the allowed values,
+// and parameters that have no default value must have a value.
+func (az *AlzLib) checkPolicyAssignments() []Finding {
+	var findings []Finding
+	for _, name := range sortedKeys(az.PolicyAssignments) {
+		pa := az.PolicyAssignments[name]
+		file := az.SourceFile(KindPolicyAssignment, name)
+		if pa.Properties == nil || pa.Properties.PolicyDefinitionID == nil || *pa.Properties.PolicyDefinitionID == "" {
+			findings = append(findings, Finding{File: file, Pointer: "/properties/policyDefinitionId", Message: fmt.Sprintf("policy assignment %s has no policy definition id", name)})
+			continue
+		}
+		schema, ok, err := az.parameterSchema(*pa.Properties.PolicyDefinitionID)
+		if err != nil {
+			findings = append(findings, Finding{File: file, Pointer: "/properties/policyDefinitionId", Message: fmt.Sprintf("policy assignment %s: %s", name, err)})
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		for _, pk := range sortedKeys(pa.Properties.Parameters) {
+			pointer := jsonPointer("properties", "parameters", pk, "value")
+			def, exists := lookupParameter(schema, pk)
+			if !exists {
+				findings = append(findings, Finding{File: file, Pointer: jsonPointer("properties", "parameters", pk), Message: fmt.Sprintf("policy assignment %s parameter %s is not declared by the policy definition", name, pk)})
+				continue
+			}
+			var value interface{}
+			if pv := pa.Properties.Parameters[pk]; pv != nil {
+				value = pv.Value
+			}
+			if err := checkParameterValue(def, value); err != nil {
+				findings = append(findings, Finding{File: file, Pointer: pointer, Message: fmt.Sprintf("policy assignment %s parameter %s %s", name, pk, err)})
+			}
+		}
+
+		for _, pk := range sortedKeys(schema) {
+			if _, exists := lookupParameter(pa.Properties.Parameters, pk); exists {
+				continue
+			}
+			if def := schema[pk]; def != nil && def.DefaultValue == nil {
+				findings = append(findings, Finding{File: file, Pointer: "/properties/parameters", Message: fmt.Sprintf("policy assignment %s parameter %s is required, it has no value and the policy definition has no default value", name, pk)})
+			}
+		}
+	}
+	return findings
+}
+
+// lookupParameter returns the named parameter from the supplied map, parameter names are not case sensitive
+func lookupParameter[T any](params map[string]T, name string) (T, bool) {
+	if v, ok := params[name]; ok {
+		return v, true
+	}
+	for k, v := range params {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// checkParameterValue checks the supplied value against the parameter definition,
+// it returns an error describing the problem if the value does not have the declared type or is not one of the allowed values.
+// Values that are ARM template expressions or contain placeholders are not checked, as they are only known at deployment.
+func checkParameterValue(def *armpolicy.ParameterDefinitionsValue, value interface{}) error {
+	if def == nil || isParameterExpression(value) {
+		return nil
+	}
+	if value == nil {
+		return fmt.Errorf("has no value")
+	}
+	if def.Type != nil && !hasParameterType(*def.Type, value) {
+		return fmt.Errorf("must be of type %s, got %s", *def.Type, parameterValueType(value))
+	}
+	if len(def.AllowedValues) == 0 {
+		return nil
+	}
+	// the allowed values of an array parameter are usually the allowed elements
+	if values, ok := value.([]interface{}); ok && !isArrayOfArrays(def.AllowedValues) {
+		for _, v := range values {
+			if !isAllowedValue(def.AllowedValues, v) {
+				return fmt.Errorf("has an element %v that is not one of the allowed values %v", v, def.AllowedValues)
+			}
+		}
+		return nil
+	}
+	if !isAllowedValue(def.AllowedValues, value) {
+		return fmt.Errorf("value %v is not one of the allowed values %v", value, def.AllowedValues)
+	}
+	return nil
+}
+
+// hasParameterType returns true if the value can be used for a parameter of the supplied type
+func hasParameterType(t armpolicy.ParameterType, value interface{}) bool {
+	switch t {
+	case armpolicy.ParameterTypeString:
+		_, ok := value.(string)
+		return ok
+	case armpolicy.ParameterTypeArray:
+		_, ok := value.([]interface{})
+		return ok
+	case armpolicy.ParameterTypeObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case armpolicy.ParameterTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case armpolicy.ParameterTypeInteger:
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case armpolicy.ParameterTypeFloat:
+		_, ok := value.(float64)
+		return ok
+	case armpolicy.ParameterTypeDateTime:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	}
+	// unknown types are not checked
+	return true
+}
+
+// parameterValueType returns the name of the parameter type of the supplied JSON value, used in error messages
+func parameterValueType(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return string(armpolicy.ParameterTypeString)
+	case []interface{}:
+		return string(armpolicy.ParameterTypeArray)
+	case map[string]interface{}:
+		return string(armpolicy.ParameterTypeObject)
+	case bool:
+		return string(armpolicy.ParameterTypeBoolean)
+	case float64:
+		if v == math.Trunc(v) {
+			return string(armpolicy.ParameterTypeInteger)
+		}
+		return string(armpolicy.ParameterTypeFloat)
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// isAllowedValue returns true if the value is one of the allowed values, strings are compared case insensitively
+func isAllowedValue(allowed []interface{}, value interface{}) bool {
+	for _, a := range allowed {
+		as, aok := a.(string)
+		vs, vok := value.(string)
+		if aok && vok && strings.EqualFold(as, vs) {
+			return true
+		}
+		if reflect.DeepEqual(a, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isArrayOfArrays returns true if all of the allowed values are arrays
+func isArrayOfArrays(allowed []interface{}) bool {
+	for _, a := range allowed {
+		if _, ok := a.([]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isParameterExpression returns true if the value is a string containing an ARM template expression,
+// or a placeholder that is rendered later
+func isParameterExpression(value interface{}) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	return strings.Contains(s, "${") || (strings.HasPrefix(s, "[") && !strings.HasPrefix(s, "[["))
+}
@@ -0,0 +1,74 @@
+package alzlib
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+	"gotest.tools/v3/assert"
+)
+
+// TestCheckPolicyAssignments tests that the assignment parameters and the archetype_config parameters
+// are checked against the parameter schema of the policy definition
+func TestCheckPolicyAssignments(t *testing.T) {
+	l, err := DirLayer("./testdata/parameters")
+	assert.NilError(t, err)
+	az, findings, err := ValidateLayers(nil, l)
+	assert.NilError(t, err)
+
+	dir := "testdata/parameters/"
+	assert.DeepEqual(t, findings, []Finding{
+		{File: dir + "policy_assignment_invalid.json", Pointer: "/properties/parameters/count/value", Message: "policy assignment invalid parameter count must be of type Integer, got Float"},
+		{File: dir + "policy_assignment_invalid.json", Pointer: "/properties/parameters/effect/value", Message: "policy assignment invalid parameter effect value Disabled is not one of the allowed values [Audit Deny]"},
+		{File: dir + "policy_assignment_invalid.json", Pointer: "/properties/parameters/extra", Message: "policy assignment invalid parameter extra is not declared by the policy definition"},
+		{File: dir + "policy_assignment_invalid.json", Pointer: "/properties/parameters/tags/value", Message: "policy assignment invalid parameter tags has an element c that is not one of the allowed values [a b]"},
+		{File: dir + "policy_assignment_missing.json", Pointer: "/properties/parameters", Message: "policy assignment missing parameter count is required, it has no value and the policy definition has no default value"},
+		{File: dir + "policy_assignment_unknown_definition.json", Pointer: "/properties/policyDefinitionId", Message: "policy assignment unknown-definition: policy definition not-in-library is not in the library"},
+		{File: dir + "archetype_definition_test.json", Pointer: "/test/archetype_config/parameters/valid/effect", Message: "archetype_config.parameters error: parameter effect in assignment valid, in archetype test value Disabled is not one of the allowed values [Audit Deny]"},
+	})
+
+	// the valid override is applied and the invalid one is not
+	params := az.Archetypes["test"].PolicyAssignments["valid"].Properties.Parameters
+	assert.Equal(t, params["count"].Value, float64(5))
+	assert.Equal(t, params["effect"].Value, "deny")
+}
+
+// TestCheckParameterValue tests the type and allowed values checks of a single parameter value
+func TestCheckParameterValue(t *testing.T) {
+	def := func(pt armpolicy.ParameterType, allowed ...interface{}) *armpolicy.ParameterDefinitionsValue {
+		return &armpolicy.ParameterDefinitionsValue{Type: &pt, AllowedValues: allowed}
+	}
+	cases := []struct {
+		name  string
+		def   *armpolicy.ParameterDefinitionsValue
+		value interface{}
+		err   string
+	}{
+		{name: "string", def: def(armpolicy.ParameterTypeString), value: "x"},
+		{name: "not a string", def: def(armpolicy.ParameterTypeString), value: true, err: "must be of type String, got Boolean"},
+		{name: "integer", def: def(armpolicy.ParameterTypeInteger), value: float64(2)},
+		{name: "float", def: def(armpolicy.ParameterTypeFloat), value: 2.5},
+		{name: "boolean", def: def(armpolicy.ParameterTypeBoolean), value: false},
+		{name: "object", def: def(armpolicy.ParameterTypeObject), value: map[string]interface{}{}},
+		{name: "not an object", def: def(armpolicy.ParameterTypeObject), value: []interface{}{}, err: "must be of type Object, got Array"},
+		{name: "datetime", def: def(armpolicy.ParameterTypeDateTime), value: "2022-01-01T00:00:00Z"},
+		{name: "not a datetime", def: def(armpolicy.ParameterTypeDateTime), value: "yesterday", err: "must be of type DateTime, got String"},
+		{name: "allowed", def: def(armpolicy.ParameterTypeString, "Audit", "Deny"), value: "audit"},
+		{name: "allowed array of arrays", def: def(armpolicy.ParameterTypeArray, []interface{}{"a"}), value: []interface{}{"a"}},
+		{name: "not allowed array of arrays", def: def(armpolicy.ParameterTypeArray, []interface{}{"a"}), value: []interface{}{"b"}, err: "value [b] is not one of the allowed values [[a]]"},
+		{name: "null", def: def(armpolicy.ParameterTypeString), value: nil, err: "has no value"},
+		{name: "expression", def: def(armpolicy.ParameterTypeInteger), value: "[parameters('count')]"},
+		{name: "escaped expression", def: def(armpolicy.ParameterTypeInteger), value: "[[not an expression", err: "must be of type Integer, got String"},
+		{name: "placeholder", def: def(armpolicy.ParameterTypeArray), value: "${default_location}"},
+		{name: "no definition", value: 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkParameterValue(c.def, c.value)
+			if c.err == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, c.err)
+		})
+	}
+}
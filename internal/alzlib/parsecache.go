@@ -0,0 +1,133 @@
+package alzlib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ParseCache is an on-disk cache of parsed lib files, so that the lib files that have not changed are not read and parsed again.
+// There is an entry for each lib file, identified by its kind and absolute path, which is used if the size and modification
+// time of the file are unchanged, without reading the file. The SHA-256 of the file is recorded in the entry when it is parsed.
+// Only the files in directory layers are cached, the files in embedded file systems and archives do not have modification
+// times that change with their content, e.g. when the provider is upgraded or an archive is replaced.
+// As nothing ties a cached object to the current file bytes, the cache must not be used when the lib files are verified,
+// and the cache directory must only be writable by the user.
+// The cache is shared by concurrent processes, entries are written by renaming a temporary file.
+// Problems reading or writing the cache are ignored, the lib file is parsed instead.
+type ParseCache struct {
+	dir string
+}
+
+// parseCacheRacyInterval is how long after a lib file is modified before it is cached.
+// A file that is modified again within the resolution of its modification time could keep the same size and
+// modification time, so recently modified files are parsed every time until they have settled.
+const parseCacheRacyInterval = 2 * time.Second
+
+// parseCacheEntry is the header of an entry in the parse cache, which identifies the lib file that was parsed.
+// The entry file contains the header on the first line followed by the parsed object,
+// so that the header can be checked without decoding the object.
+// The object is stored as compact JSON, as this is the encoding that preserves empty values.
+type parseCacheEntry struct {
+	Path    string     `json:"path"`
+	Kind    ObjectKind `json:"kind"`
+	Size    int64      `json:"size"`
+	ModTime time.Time  `json:"mod_time"`
+	Sha256  string     `json:"sha256"`
+}
+
+// NewParseCache returns a parse cache that stores its entries in the supplied directory, which is created if it does not exist
+func NewParseCache(dir string) (*ParseCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating parse cache directory %s: %s", dir, err)
+	}
+	return &ParseCache{dir: dir}, nil
+}
+
+// entryPath returns the path of the cache entry of the lib file
+func (c *ParseCache) entryPath(entry parseCacheEntry) string {
+	sum := sha256.Sum256([]byte(string(entry.Kind) + "\x00" + entry.Path))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// stat returns the cache entry of the lib file in the layer, with the size and modification time of the file,
+// without the SHA-256. The boolean is false if the cache is nil, the layer is not a directory layer,
+// or the file cannot be stat'ed.
+// The file is stat'ed before it is read, so that a change made while it is read results in a different entry next time.
+func (c *ParseCache) stat(l LibLayer, f *libFile) (parseCacheEntry, bool) {
+	if c == nil || l.dir == "" {
+		return parseCacheEntry{}, false
+	}
+	fi, err := fs.Stat(l.FS, f.name)
+	if err != nil {
+		return parseCacheEntry{}, false
+	}
+	return parseCacheEntry{
+		Path:    filepath.Join(l.dir, filepath.FromSlash(f.name)),
+		Kind:    f.kind,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	}, true
+}
+
+// lookup returns the cached object of the lib file, decoded by the processor, and the SHA-256 of the file when it was parsed.
+// The boolean is false if there is no entry for the file, or the file has changed since the entry was stored.
+func (c *ParseCache) lookup(want parseCacheEntry, p libFileProcessor) (interface{}, string, bool) {
+	data, err := os.ReadFile(c.entryPath(want))
+	if err != nil {
+		return nil, "", false
+	}
+	header, object, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return nil, "", false
+	}
+	var got parseCacheEntry
+	if err := json.Unmarshal(header, &got); err != nil {
+		return nil, "", false
+	}
+	if got.Path != want.Path || got.Kind != want.Kind || got.Size != want.Size || !got.ModTime.Equal(want.ModTime) || got.Sha256 == "" {
+		return nil, "", false
+	}
+	obj, err := p.decode(object)
+	if err != nil {
+		return nil, "", false
+	}
+	return obj, got.Sha256, true
+}
+
+// store writes the parsed object of the lib file to the cache, with the entry returned by stat before the file was read,
+// replacing any existing entry for the file. Recently modified files are not stored.
+func (c *ParseCache) store(entry parseCacheEntry, f *libFile) {
+	if time.Since(entry.ModTime) < parseCacheRacyInterval {
+		return
+	}
+	entry.Sha256 = f.sha256
+	header, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	object, err := json.Marshal(f.obj)
+	if err != nil {
+		return
+	}
+	data := append(append(header, '\n'), object...)
+	tmp, err := os.CreateTemp(c.dir, "tmp-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), c.entryPath(entry))
+}
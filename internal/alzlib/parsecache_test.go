@@ -0,0 +1,232 @@
+package alzlib
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+// cachedLayer returns a layer for the directory that uses a parse cache in a temporary directory
+func cachedLayer(t *testing.T, dir string) LibLayer {
+	t.Helper()
+	cache, err := NewParseCache(t.TempDir())
+	assert.NilError(t, err)
+	l, err := DirLayer(dir)
+	assert.NilError(t, err)
+	l.Cache = cache
+	return l
+}
+
+// libFingerprint returns a fingerprint of the objects and processed files of the library
+func libFingerprint(t *testing.T, az *AlzLib) string {
+	t.Helper()
+	fp, err := Fingerprint([]interface{}{az.PolicyDefinitions, az.PolicySetDefinitions, az.PolicyAssignments, az.RoleDefinitions, az.Files()})
+	assert.NilError(t, err)
+	return fp
+}
+
+// settledLibDir returns a copy of the test library, with modification times in the past so that the lib files are cached
+func settledLibDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	err := filepath.WalkDir(testLibDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(testLibDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+		backdate(t, target, time.Hour)
+		return nil
+	})
+	assert.NilError(t, err)
+	return dir
+}
+
+// TestParseCache tests that a library loaded from the parse cache is the same as one that is parsed,
+// and that there is an entry for each lib file
+func TestParseCache(t *testing.T) {
+	dir := settledLibDir(t)
+	want, err := NewAlzLib(dir)
+	assert.NilError(t, err)
+
+	l := cachedLayer(t, dir)
+	for i := 0; i < 2; i++ {
+		az, err := NewAlzLibFromLayers(l)
+		assert.NilError(t, err)
+		assert.Equal(t, libFingerprint(t, az), libFingerprint(t, want))
+	}
+
+	entries, err := os.ReadDir(l.Cache.dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), len(want.Files()))
+}
+
+// backdate sets the modification time of the lib file in the past, so that it is not too recently modified to be cached
+func backdate(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	mtime := time.Now().Add(-d)
+	assert.NilError(t, os.Chtimes(path, mtime, mtime))
+}
+
+// TestParseCacheChangedFile tests that a cache entry is used without reading the lib file if the file has not changed,
+// and that it is not used if the file has changed
+func TestParseCacheChangedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy_definition_a.json")
+	writeLibFiles(t, dir, map[string]string{
+		"policy_definition_a.json": `{"name": "a", "properties": {"displayName": "A"}}`,
+	})
+	backdate(t, path, time.Hour)
+	l := cachedLayer(t, dir)
+	want, err := NewAlzLibFromLayers(l)
+	assert.NilError(t, err)
+
+	// change the cached object, so that we can tell when it is used
+	entry, ok := l.Cache.stat(l, &libFile{name: "policy_definition_a.json", kind: KindPolicyDefinition})
+	assert.Assert(t, ok)
+	assert.Assert(t, filepath.IsAbs(entry.Path))
+	data, err := os.ReadFile(l.Cache.entryPath(entry))
+	assert.NilError(t, err)
+	header, _, ok := bytes.Cut(data, []byte("\n"))
+	assert.Assert(t, ok)
+	data = append(append(header, '\n'), `{"name": "a", "properties": {"displayName": "cached"}}`...)
+	assert.NilError(t, os.WriteFile(l.Cache.entryPath(entry), data, 0o644))
+
+	az, err := NewAlzLibFromLayers(l)
+	assert.NilError(t, err)
+	assert.Equal(t, *az.PolicyDefinitions["a"].Properties.DisplayName, "cached")
+	// the SHA-256 recorded when the file was parsed is reported
+	assert.DeepEqual(t, az.Files(), want.Files())
+
+	writeLibFiles(t, dir, map[string]string{
+		"policy_definition_a.json": `{"name": "a", "properties": {"displayName": "B"}}`,
+	})
+	backdate(t, path, time.Minute)
+	az, err = NewAlzLibFromLayers(l)
+	assert.NilError(t, err)
+	assert.Equal(t, *az.PolicyDefinitions["a"].Properties.DisplayName, "B")
+	assert.Assert(t, az.Files()[0].Sha256 != want.Files()[0].Sha256)
+}
+
+// TestParseCacheNotDirectoryLayer tests that the files in layers that are not directories, e.g. the embedded library
+// and archives, are not cached, so a file that is replaced with one of the same size and modification time is parsed again
+func TestParseCacheNotDirectoryLayer(t *testing.T) {
+	// zip archives store modification times to the second
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	data := func(name string) []byte {
+		return []byte(`{"name": "a", "properties": {"displayName": "` + name + `"}}`)
+	}
+	archive := filepath.Join(t.TempDir(), "lib.zip")
+	writeZip := func(name string) {
+		f, err := os.Create(archive)
+		assert.NilError(t, err)
+		zw := zip.NewWriter(f)
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: "policy_definition_a.json", Modified: mtime})
+		assert.NilError(t, err)
+		_, err = w.Write(data(name))
+		assert.NilError(t, err)
+		assert.NilError(t, zw.Close())
+		assert.NilError(t, f.Close())
+	}
+	fsys := fstest.MapFS{}
+	layers := map[string]func(t *testing.T) LibLayer{
+		"embedded": func(t *testing.T) LibLayer {
+			return LibLayer{Name: "embedded", FS: fsys}
+		},
+		"archive": func(t *testing.T) LibLayer {
+			l, err := ArchiveLayer(archive, "")
+			assert.NilError(t, err)
+			return l
+		},
+	}
+
+	for name, layer := range layers {
+		t.Run(name, func(t *testing.T) {
+			cache, err := NewParseCache(t.TempDir())
+			assert.NilError(t, err)
+			for _, displayName := range []string{"A", "B"} {
+				fsys["policy_definition_a.json"] = &fstest.MapFile{Data: data(displayName), ModTime: mtime}
+				writeZip(displayName)
+				l := layer(t)
+				l.Cache = cache
+				az, err := NewAlzLibFromLayers(l)
+				assert.NilError(t, err)
+				assert.Equal(t, *az.PolicyDefinitions["a"].Properties.DisplayName, displayName)
+			}
+			entries, err := os.ReadDir(cache.dir)
+			assert.NilError(t, err)
+			assert.Equal(t, len(entries), 0)
+		})
+	}
+}
+
+// TestParseCacheRecentlyModifiedFile tests that recently modified files are not cached,
+// as they could be modified again without changing their size and modification time
+func TestParseCacheRecentlyModifiedFile(t *testing.T) {
+	dir := t.TempDir()
+	writeLibFiles(t, dir, map[string]string{
+		"policy_definition_a.json": `{"name": "a", "properties": {"displayName": "A"}}`,
+	})
+	l := cachedLayer(t, dir)
+	_, err := NewAlzLibFromLayers(l)
+	assert.NilError(t, err)
+	entries, err := os.ReadDir(l.Cache.dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+}
+
+// TestParseCacheInvalidFile tests that files with problems are not cached, so the problems are reported every time
+func TestParseCacheInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	writeLibFiles(t, dir, map[string]string{
+		"policy_definition_a.json": `{"properties": {}}`,
+	})
+	l := cachedLayer(t, dir)
+	for i := 0; i < 2; i++ {
+		_, err := NewAlzLibFromLayers(l)
+		assert.ErrorContains(t, err, "policy definition name is empty or not present")
+	}
+	entries, err := os.ReadDir(l.Cache.dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+}
+
+// TestParseLibFilesDeterministic tests that the lib files are added in walk order when they are parsed concurrently,
+// so that the processed files and the problems are always reported in the same order
+func TestParseLibFilesDeterministic(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+
+	want, err := NewAlzLib(testLibDir)
+	assert.NilError(t, err)
+	for i := 0; i < 5; i++ {
+		az, err := NewAlzLib(testLibDir)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, az.Files(), want.Files())
+	}
+
+	for i := 0; i < 5; i++ {
+		_, err := NewAlzLib("./testdata/layers/base", "./testdata/layers/duplicate")
+		assert.ErrorContains(t, err, "declared in files testdata/layers/duplicate/policy_definition_test_policy_a.json and testdata/layers/duplicate/policy_definition_test_policy_b.json")
+	}
+}
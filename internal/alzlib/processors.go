@@ -0,0 +1,284 @@
+package alzlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+)
+
+// libFileProcessors are the processors for each kind of lib file
+var libFileProcessors = map[ObjectKind]libFileProcessor{
+	KindArchetypeDefinition: newLibFileProcessor(parseArchetypeDefinition, addArchetypeDefinition),
+	KindArchetypeExclusion:  newLibFileProcessor(parseArchetypeDefinition, addArchetypeExclusion),
+	KindArchetypeExtension:  newLibFileProcessor(parseArchetypeDefinition, addArchetypeExtension),
+	KindPolicyAssignment:    newLibFileProcessor(parsePolicyAssignment, addPolicyAssignment),
+	KindPolicyDefinition:    newLibFileProcessor(parsePolicyDefinition, addPolicyDefinition),
+	KindPolicySetDefinition: newLibFileProcessor(parsePolicySetDefinition, addPolicySetDefinition),
+	KindRoleDefinition:      newLibFileProcessor(parseRoleDefinition, addRoleDefinition),
+}
+
+// newLibFileProcessor returns a libFileProcessor for the lib files that declare objects of type T
+func newLibFileProcessor[T any](parse func(data []byte) (*T, error), add func(az *AlzLib, path string, obj *T) error) libFileProcessor {
+	return libFileProcessor{
+		parse: func(data []byte) (interface{}, error) {
+			return parse(data)
+		},
+		decode: func(data []byte) (interface{}, error) {
+			obj := new(T)
+			return obj, json.Unmarshal(data, obj)
+		},
+		add: func(az *AlzLib, path string, obj interface{}) error {
+			return add(az, path, obj.(*T))
+		},
+	}
+}
+
+// parseArchetypeDefinition parses the bytes of an archetype_definition, archetype_extension or archetype_exclusion file
+func parseArchetypeDefinition(data []byte) (*LibArchetypeDefinition, error) {
+	lad, err := getLibArchetypeDefinition(data)
+	if perr, ok := err.(*pointerError); ok {
+		return nil, errorAt(perr.pointer, "error processing archetype definition: %s", perr.err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error processing archetype definition: %s", err)
+	}
+	return lad, nil
+}
+
+// addArchetypeDefinition adds the LibArchetypeDefinition to the AlzLib
+func addArchetypeDefinition(az *AlzLib, path string, lad *LibArchetypeDefinition) error {
+	lad.file, lad.pointer = path, jsonPointer(lad.Id)
+	if err := az.setSource(KindArchetypeDefinition, lad.Id, path); err != nil {
+		return err
+	}
+	// an archetype definition in a later layer replaces the one in the earlier layer
+	for i, existing := range az.libArchetypeDefinitions {
+		if existing.Id == lad.Id {
+			az.libArchetypeDefinitions[i] = lad
+			return nil
+		}
+	}
+	az.libArchetypeDefinitions = append(az.libArchetypeDefinitions, lad)
+	return nil
+}
+
+// addArchetypeExtension adds the archetype extension to the AlzLib, to be applied to its archetype definition
+func addArchetypeExtension(az *AlzLib, path string, ext *LibArchetypeDefinition) error {
+	ext.file, ext.pointer = path, jsonPointer(ext.Id)
+	// remove the prefix so that we can match the id to the definition
+	ext.Id = strings.Replace(ext.Id, "extend_", "", 1)
+	az.setFileObjectName(ext.Id)
+	az.libArchetypeExtensions = append(az.libArchetypeExtensions, ext)
+	return nil
+}
+
+// addArchetypeExclusion adds the archetype exclusion to the AlzLib, to be removed from its archetype definition
+func addArchetypeExclusion(az *AlzLib, path string, excl *LibArchetypeDefinition) error {
+	excl.file, excl.pointer = path, jsonPointer(excl.Id)
+	// remove the prefix so that we can match the id to the definition
+	excl.Id = strings.Replace(excl.Id, "exclude_", "", 1)
+	az.setFileObjectName(excl.Id)
+	az.libArchetypeExclusions = append(az.libArchetypeExclusions, excl)
+	return nil
+}
+
+// parsePolicyAssignment parses the bytes of a policy_assignment file
+func parsePolicyAssignment(data []byte) (*armpolicy.Assignment, error) {
+	pa := &armpolicy.Assignment{}
+	if err := json.Unmarshal(data, pa); err != nil {
+		return nil, fmt.Errorf("error unmarshalling policy assignment: %s", err)
+	}
+	if pa.Name == nil || *pa.Name == "" {
+		return nil, errorAt("/name", "policy assignment name is empty or not present")
+	}
+	return pa, nil
+}
+
+// addPolicyAssignment adds the armpolicy.Assignment to the AlzLib
+func addPolicyAssignment(az *AlzLib, path string, pa *armpolicy.Assignment) error {
+	if err := az.setSource(KindPolicyAssignment, *pa.Name, path); err != nil {
+		return err
+	}
+	az.PolicyAssignments[*pa.Name] = pa
+	return nil
+}
+
+// parsePolicyDefinition parses the bytes of a policy_definition file
+func parsePolicyDefinition(data []byte) (*armpolicy.Definition, error) {
+	pd := &armpolicy.Definition{}
+	if err := json.Unmarshal(data, pd); err != nil {
+		return nil, fmt.Errorf("error unmarshalling policy definition: %s", err)
+	}
+	if pd.Name == nil || *pd.Name == "" {
+		return nil, errorAt("/name", "policy definition name is empty or not present")
+	}
+	return pd, nil
+}
+
+// addPolicyDefinition adds the armpolicy.Definition to the AlzLib
+func addPolicyDefinition(az *AlzLib, path string, pd *armpolicy.Definition) error {
+	if err := az.setSource(KindPolicyDefinition, *pd.Name, path); err != nil {
+		return err
+	}
+	az.PolicyDefinitions[*pd.Name] = pd
+	return nil
+}
+
+// parsePolicySetDefinition parses the bytes of a policy_set_definition file
+func parsePolicySetDefinition(data []byte) (*armpolicy.SetDefinition, error) {
+	psd := &armpolicy.SetDefinition{}
+	if err := json.Unmarshal(data, psd); err != nil {
+		return nil, fmt.Errorf("error unmarshalling policy set definition: %s", err)
+	}
+	if psd.Name == nil || *psd.Name == "" {
+		return nil, errorAt("/name", "policy set definition name is empty or not present")
+	}
+	return psd, nil
+}
+
+// addPolicySetDefinition adds the armpolicy.SetDefinition to the AlzLib
+func addPolicySetDefinition(az *AlzLib, path string, psd *armpolicy.SetDefinition) error {
+	if err := az.setSource(KindPolicySetDefinition, *psd.Name, path); err != nil {
+		return err
+	}
+	az.PolicySetDefinitions[*psd.Name] = psd
+	return nil
+}
+
+// parseRoleDefinition parses the bytes of a role_definition file
+func parseRoleDefinition(data []byte) (*RoleDefinition, error) {
+	rd := &RoleDefinition{}
+	if err := json.Unmarshal(data, rd); err != nil {
+		return nil, fmt.Errorf("error unmarshalling role definition: %s", err)
+	}
+	if rd.Name == nil || *rd.Name == "" {
+		return nil, errorAt("/name", "role definition name is empty or not present")
+	}
+	if rd.Properties == nil || rd.Properties.RoleName == nil || *rd.Properties.RoleName == "" {
+		return nil, errorAt("/properties/roleName", "role definition %s role name is empty or not present", *rd.Name)
+	}
+	return rd, nil
+}
+
+// addRoleDefinition adds the RoleDefinition to the AlzLib.
+// Role definitions are keyed by role name, as this is how they are referenced in the archetype definitions.
+func addRoleDefinition(az *AlzLib, path string, rd *RoleDefinition) error {
+	// the role definition id must also be unique, as it is the name of the deployed role definition
+	replaced := ""
+	for roleName, existing := range az.RoleDefinitions {
+		if roleName == *rd.Properties.RoleName || existing.Name == nil || *existing.Name != *rd.Name {
+			continue
+		}
+		src := az.sources[objectKey{kind: KindRoleDefinition, name: roleName}]
+		if src.Layer == az.layer {
+			return errorAt("/name", "duplicate role definition id: %s, declared in files %s and %s", *rd.Name, src.File, path)
+		}
+		replaced = roleName
+	}
+	if err := az.setSource(KindRoleDefinition, *rd.Properties.RoleName, path); err != nil {
+		return err
+	}
+	// a role definition in a later layer with the same id replaces the earlier one, even if it has been renamed
+	if replaced != "" {
+		src := az.sources[objectKey{kind: KindRoleDefinition, name: *rd.Properties.RoleName}]
+		src.Replaces = az.sources[objectKey{kind: KindRoleDefinition, name: replaced}].File
+		az.sources[objectKey{kind: KindRoleDefinition, name: *rd.Properties.RoleName}] = src
+		delete(az.sources, objectKey{kind: KindRoleDefinition, name: replaced})
+		delete(az.RoleDefinitions, replaced)
+	}
+	az.RoleDefinitions[*rd.Properties.RoleName] = rd
+	return nil
+}
+
+// setSource records the file path and layer that the named library object was read from.
+// Objects in later layers override those in earlier layers, this is the way to replace an object intentionally,
+// but an error naming both files is returned if the object has already been read from another file in the same layer.
+func (az *AlzLib) setSource(kind ObjectKind, name, path string) error {
+	if az.sources == nil {
+		az.sources = make(map[objectKey]ObjectSource)
+	}
+	az.setFileObjectName(name)
+	key := objectKey{kind: kind, name: name}
+	existing, exists := az.sources[key]
+	if exists && existing.Layer == az.layer {
+		return errorAt(kind.namePointer(name), "duplicate %s: %s, declared in files %s and %s", kind.description(), name, existing.File, path)
+	}
+	src := ObjectSource{
+		File:  path,
+		Layer: az.layer,
+	}
+	if exists {
+		src.Replaces = existing.File
+	}
+	if az.layer < len(az.layers) {
+		src.LayerPath = az.layers[az.layer]
+	}
+	az.sources[key] = src
+	return nil
+}
+
+// Source returns where the named library object was read from.
+// The boolean is false if the object is not known.
+func (az *AlzLib) Source(kind ObjectKind, name string) (ObjectSource, bool) {
+	src, ok := az.sources[objectKey{kind: kind, name: name}]
+	return src, ok
+}
+
+// SourceFile returns the path of the lib file that the named library object was read from,
+// or an empty string if the object is not known
+func (az *AlzLib) SourceFile(kind ObjectKind, name string) string {
+	return az.sources[objectKey{kind: kind, name: name}].File
+}
+
+// Layers returns the library directories, in the order that they were processed
+func (az *AlzLib) Layers() []string {
+	return append([]string{}, az.layers...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////
+// Helper funcs
+///////////////////////////////////////////////////////////////////////////////
+///////////////////////////////////////////////////////////////////////////////
+
+// getLibArchetypeDefinition returns the same from the bytes of the
+// archetype_[definition,extension,exclusion] file
+// it is used because we do not know the JSON key name from the archetype definition file
+func getLibArchetypeDefinition(data []byte) (*LibArchetypeDefinition, error) {
+	// Top level object key name is unknowable, so we need to unmarshal into a map
+	parent := make(map[string]interface{})
+	child := make([]byte, 0)
+	id := ""
+
+	if err := json.Unmarshal(data, &parent); err != nil {
+		return nil, fmt.Errorf("error marshalling LibArchetypeDefinition JSON object: %s", err)
+	}
+
+	// check we only have 1 top level object
+	if len(parent) != 1 {
+		return nil, fmt.Errorf("expected 1 top-level object, got %d", len(parent))
+	}
+
+	// We know there is one top level object, but we don't know what it's called, so use range
+	for k := range parent {
+		id = k
+		m, ok := parent[k].(map[string]interface{})
+		if !ok {
+			return nil, errorAt(jsonPointer(k), "archetype %s must be a JSON object", k)
+		}
+		c, err := json.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling child JSON object for %s: %s", k, err)
+		}
+		child = c
+	}
+	ad := &LibArchetypeDefinition{
+		Id: id,
+	}
+	if err := json.Unmarshal(child, &ad); err != nil {
+		return nil, fmt.Errorf("error processing archetype definition: %s", err)
+	}
+	return ad, nil
+}
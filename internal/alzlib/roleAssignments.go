@@ -0,0 +1,160 @@
+package alzlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// roleDefinitionIdPrefix is the resource id prefix of a role definition
+const roleDefinitionIdPrefix = "/providers/Microsoft.Authorization/roleDefinitions/"
+
+// roleAssignmentNamespace is the namespace used to generate deterministic role assignment names
+var roleAssignmentNamespace = uuid.MustParse("98e4c8d0-28a9-43c2-a1c2-b7ba5aaf92b0")
+
+// RoleAssignment represents the assignment of a role to a principal at the scope of an archetype.
+// It is generated from the archetype_config.access_control section of the archetype definition.
+type RoleAssignment struct {
+	Name               string
+	RoleDefinitionName string
+	RoleDefinitionId   string
+	PrincipalId        string
+	Scope              string
+}
+
+// builtInRoleDefinitions maps the names of commonly used built-in roles to their role definition ids.
+// Roles that are not in this list can be used by supplying the role definition resource id as the
+// access_control key.
+var builtInRoleDefinitions = map[string]string{
+	"Automation Contributor":          "f353d9bd-d4a6-484e-a77a-8050b599b867",
+	"Backup Contributor":              "5e467623-bb1f-42f4-a55d-6e525e11384b",
+	"Billing Reader":                  "fa23ad8b-c56e-40d8-ac0c-ce449e1d2c64",
+	"Contributor":                     "b24988ac-6180-42a0-ab88-20f7382dd24c",
+	"Cost Management Contributor":     "434105ed-43f6-45c7-a02f-909b2ba83430",
+	"Cost Management Reader":          "72fafb9e-0641-4937-9268-a91bfd8191a3",
+	"Key Vault Administrator":         "00482a5a-887f-4fb3-b363-3b7fe8e74483",
+	"Log Analytics Contributor":       "92aaf0da-9dab-42b6-94a3-d43ce8d16293",
+	"Log Analytics Reader":            "73c42c96-874c-492b-b04d-ab87d138a893",
+	"Management Group Contributor":    "5d58bcaf-24a5-4b20-bdb6-eed9f69fbe4c",
+	"Management Group Reader":         "ac63b705-f282-497d-ac71-919bf39d939d",
+	"Monitoring Contributor":          "749f88d5-cbae-40b8-bcfc-e573ddc772fa",
+	"Monitoring Reader":               "43d0d8ad-25c7-4714-9337-8ba259a9fe05",
+	"Network Contributor":             "4d97b98b-1d4f-4787-a291-c67834d212e7",
+	"Owner":                           "8e3af657-a8ff-443c-a75c-2fe8c4bcb635",
+	"Private DNS Zone Contributor":    "b12aa53e-6015-4669-85d0-8515ebb3ae7f",
+	"Reader":                          "acdd72a7-3385-48ef-bd42-f606fba81ae7",
+	"Resource Policy Contributor":     "36243c78-bf99-498c-9df9-86d9f8d28608",
+	"Security Admin":                  "fb1c8493-542b-48eb-b624-b4c8fea62acd",
+	"Security Assessment Contributor": "612c2aa1-cb24-443b-ac28-3ab7272de6f5",
+	"Security Reader":                 "39bc4728-0917-49c7-9d2c-d95423bc2eb4",
+	"Storage Account Contributor":     "17d1049b-9a84-46fb-8f53-869881c3d3ab",
+	"User Access Administrator":       "18d7d88d-d35e-4fb5-a5c3-7773c20a72d9",
+	"Virtual Machine Contributor":     "9980e02c-c2be-4d73-94e8-173b1dc7cf3c",
+}
+
+// newRoleAssignment creates a role assignment with a deterministic name,
+// generated from the scope, role definition id and principal id
+func newRoleAssignment(roleName, roleDefinitionId, principalId, scope string) RoleAssignment {
+	ra := RoleAssignment{
+		RoleDefinitionName: roleName,
+		RoleDefinitionId:   roleDefinitionId,
+		PrincipalId:        principalId,
+		Scope:              scope,
+	}
+	ra.Name = ra.generateName()
+	return ra
+}
+
+// generateName returns the deterministic name of the role assignment
+func (ra RoleAssignment) generateName() string {
+	data := strings.Join([]string{ra.Scope, ra.RoleDefinitionId, ra.PrincipalId}, "|")
+	return uuid.NewSHA1(roleAssignmentNamespace, []byte(data)).String()
+}
+
+// resolveRoleDefinitionId returns the role definition id for the supplied access_control role,
+// which can be a library role name, a built-in role name, or a role definition resource id
+func (az *AlzLib) resolveRoleDefinitionId(role string) (string, error) {
+	if rd, ok := az.RoleDefinitions[role]; ok {
+		return fmt.Sprintf("${%s}%s%s", TemplateVariableRootScopeResourceId, roleDefinitionIdPrefix, *rd.Name), nil
+	}
+	if id, ok := builtInRoleDefinitions[role]; ok {
+		return roleDefinitionIdPrefix + id, nil
+	}
+	if strings.Contains(strings.ToLower(role), strings.ToLower(roleDefinitionIdPrefix)) {
+		return role, nil
+	}
+	return "", fmt.Errorf("role %s is not a library role definition, a known built-in role, or a role definition id", role)
+}
+
+// generateRoleAssignments creates the role assignments from the supplied archetype_config.access_control map,
+// which maps role names to lists of principal ids
+func (az *AlzLib) generateRoleAssignments(archetype string, accessControl map[string]interface{}) (map[string]RoleAssignment, error) {
+	result := make(map[string]RoleAssignment)
+
+	// sort the roles so that any error is deterministic
+	roles := make([]string, 0, len(accessControl))
+	for role := range accessControl {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	for _, role := range roles {
+		principals, ok := accessControl[role].([]interface{})
+		if !ok {
+			return nil, errorAt(jsonPointer(role), "archetype_config.access_control error: role %s in archetype %s must be a list of principal ids", role, archetype)
+		}
+		roleDefinitionId, err := az.resolveRoleDefinitionId(role)
+		if err != nil {
+			return nil, errorAt(jsonPointer(role), "archetype_config.access_control error in archetype %s: %s", archetype, err)
+		}
+		for i, p := range principals {
+			principalId, ok := p.(string)
+			if !ok || principalId == "" {
+				return nil, errorAt(jsonPointer(role, i), "archetype_config.access_control error: role %s in archetype %s has a principal id that is not a non-empty string", role, archetype)
+			}
+			ra := newRoleAssignment(role, roleDefinitionId, principalId, fmt.Sprintf("${%s}", TemplateVariableCurrentScopeResourceId))
+			result[ra.Name] = ra
+		}
+	}
+	return result, nil
+}
+
+// RenderRoleAssignment returns a copy of the supplied role assignment of the named archetype with the placeholders rendered.
+// The name is regenerated from the rendered values, so that it is unique to the scope.
+func (az *AlzLib) RenderRoleAssignment(archetype string, ra RoleAssignment, vars TemplateVariables) (*RoleAssignment, error) {
+	fields := []*string{&ra.RoleDefinitionId, &ra.PrincipalId, &ra.Scope}
+	for _, f := range fields {
+		rendered, err := renderString(*f, vars)
+		if err != nil {
+			if te, ok := err.(*TemplateError); ok {
+				te.Kind = KindArchetypeDefinition
+				te.Name = archetype
+				te.File = az.SourceFile(KindArchetypeDefinition, archetype)
+			}
+			return nil, err
+		}
+		*f = rendered
+	}
+	ra.Name = ra.generateName()
+	return &ra, nil
+}
+
+// renderString renders the placeholders in the supplied string
+func renderString(s string, vars TemplateVariables) (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := RenderTemplate(data, vars)
+	if err != nil {
+		return "", err
+	}
+	result := ""
+	if err := json.Unmarshal(rendered, &result); err != nil {
+		return "", err
+	}
+	return result, nil
+}
@@ -0,0 +1,108 @@
+package alzlib
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestGenerateArchetypesAccessControl tests that role assignments are generated from the archetype_config.access_control
+// for both library and built-in roles
+func TestGenerateArchetypesAccessControl(t *testing.T) {
+	archetype := "testarchetype"
+	rdname := "00000000-0000-0000-0000-000000000001"
+	rolename := "testrole1"
+
+	az := AlzLib{
+		libArchetypeDefinitions: []*LibArchetypeDefinition{
+			{
+				Id: archetype,
+				Config: &libArchetypeDefinitionConfig{
+					AccessControl: map[string]interface{}{
+						rolename: []interface{}{"principal1", "principal2"},
+						"Reader": []interface{}{"principal1"},
+					},
+				},
+			},
+		},
+		RoleDefinitions: map[string]*RoleDefinition{
+			rolename: {
+				Name:       &rdname,
+				Properties: &RoleDefinitionProperties{RoleName: &rolename},
+			},
+		},
+		Archetypes: map[string]*ArchetypeDefinition{},
+	}
+
+	assert.NilError(t, az.generateArchetypes())
+	ras := az.Archetypes[archetype].RoleAssignments
+	assert.Equal(t, len(ras), 3)
+
+	found := make(map[string]RoleAssignment)
+	for k, ra := range ras {
+		assert.Equal(t, k, ra.Name)
+		assert.Equal(t, ra.Scope, "${current_scope_resource_id}")
+		found[ra.RoleDefinitionName+"/"+ra.PrincipalId] = ra
+	}
+	assert.Equal(t, found["Reader/principal1"].RoleDefinitionId, "/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7")
+	assert.Equal(t, found["testrole1/principal2"].RoleDefinitionId, "${root_scope_resource_id}/providers/Microsoft.Authorization/roleDefinitions/"+rdname)
+}
+
+// TestGenerateArchetypesAccessControlUnknownRole tests that an access_control role that is neither in the library
+// nor a known built-in role produces the correct error
+func TestGenerateArchetypesAccessControlUnknownRole(t *testing.T) {
+	az := AlzLib{
+		libArchetypeDefinitions: []*LibArchetypeDefinition{
+			{
+				Id: "testarchetype",
+				Config: &libArchetypeDefinitionConfig{
+					AccessControl: map[string]interface{}{
+						"Not A Role": []interface{}{"principal1"},
+					},
+				},
+			},
+		},
+		Archetypes: map[string]*ArchetypeDefinition{},
+	}
+
+	assert.ErrorContains(t, az.generateArchetypes(), "archetype_config.access_control error in archetype testarchetype: role Not A Role is not a library role definition")
+}
+
+// TestGenerateArchetypesAccessControlNotAList tests that an access_control value that is not a list
+// produces the correct error
+func TestGenerateArchetypesAccessControlNotAList(t *testing.T) {
+	az := AlzLib{
+		libArchetypeDefinitions: []*LibArchetypeDefinition{
+			{
+				Id: "testarchetype",
+				Config: &libArchetypeDefinitionConfig{
+					AccessControl: map[string]interface{}{
+						"Reader": "principal1",
+					},
+				},
+			},
+		},
+		Archetypes: map[string]*ArchetypeDefinition{},
+	}
+
+	assert.ErrorContains(t, az.generateArchetypes(), "role Reader in archetype testarchetype must be a list of principal ids")
+}
+
+// TestRenderRoleAssignment tests that rendering a role assignment renders the scope
+// and regenerates the name, so that the same principal and role produce a different name at each scope
+func TestRenderRoleAssignment(t *testing.T) {
+	az := &AlzLib{}
+	ra := newRoleAssignment("Reader", "/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7", "principal1", "${current_scope_resource_id}")
+
+	corp, err := az.RenderRoleAssignment("es_corp", ra, TemplateVariables{}.WithScope("alz-corp"))
+	assert.NilError(t, err)
+	assert.Equal(t, corp.Scope, "/providers/Microsoft.Management/managementGroups/alz-corp")
+
+	online, err := az.RenderRoleAssignment("es_online", ra, TemplateVariables{}.WithScope("alz-online"))
+	assert.NilError(t, err)
+	assert.Assert(t, corp.Name != online.Name)
+
+	again, err := az.RenderRoleAssignment("es_corp", ra, TemplateVariables{}.WithScope("alz-corp"))
+	assert.NilError(t, err)
+	assert.Equal(t, corp.Name, again.Name)
+}
@@ -0,0 +1,166 @@
+package alzlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+)
+
+// These are the well-known template variables that are used in the lib files
+const (
+	TemplateVariableCurrentScopeId         = "current_scope_id"
+	TemplateVariableCurrentScopeResourceId = "current_scope_resource_id"
+	TemplateVariableDefaultLocation        = "default_location"
+	TemplateVariablePrivateDnsZonePrefix   = "private_dns_zone_prefix"
+	TemplateVariableRootScopeId            = "root_scope_id"
+	TemplateVariableRootScopeResourceId    = "root_scope_resource_id"
+)
+
+// managementGroupResourceIdPrefix is prepended to a management group id to create its resource id
+const managementGroupResourceIdPrefix = "/providers/Microsoft.Management/managementGroups/"
+
+// templatePlaceholderRegex matches the `${...}` placeholders in the lib files
+var templatePlaceholderRegex = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// TemplateVariables are the values used to render the `${...}` placeholders in the lib files.
+// The map key is the variable name without the surrounding `${` and `}`.
+type TemplateVariables map[string]string
+
+// Merge returns a new TemplateVariables containing the receiver's values,
+// overwritten by any values in the supplied TemplateVariables
+func (tv TemplateVariables) Merge(other TemplateVariables) TemplateVariables {
+	result := make(TemplateVariables, len(tv)+len(other))
+	for k, v := range tv {
+		result[k] = v
+	}
+	for k, v := range other {
+		result[k] = v
+	}
+	return result
+}
+
+// WithScope returns a new TemplateVariables with the current scope set to the supplied management group id
+func (tv TemplateVariables) WithScope(managementGroupId string) TemplateVariables {
+	return tv.Merge(TemplateVariables{
+		TemplateVariableCurrentScopeId:         managementGroupId,
+		TemplateVariableCurrentScopeResourceId: managementGroupResourceIdPrefix + managementGroupId,
+	})
+}
+
+// withDerivedValues returns a new TemplateVariables with the resource ids filled in from the
+// scope ids, if they have not been explicitly supplied
+func (tv TemplateVariables) withDerivedValues() TemplateVariables {
+	result := tv.Merge(nil)
+	derived := map[string]string{
+		TemplateVariableRootScopeResourceId:    TemplateVariableRootScopeId,
+		TemplateVariableCurrentScopeResourceId: TemplateVariableCurrentScopeId,
+	}
+	for resourceIdVar, idVar := range derived {
+		if _, exists := result[resourceIdVar]; exists {
+			continue
+		}
+		if id, ok := result[idVar]; ok {
+			result[resourceIdVar] = managementGroupResourceIdPrefix + id
+		}
+	}
+	return result
+}
+
+// TemplateError is returned when a lib file contains placeholders that have no value
+type TemplateError struct {
+	Kind      ObjectKind
+	Name      string
+	File      string
+	Variables []string
+}
+
+// Error implements the error interface
+func (e *TemplateError) Error() string {
+	src := e.File
+	if src == "" {
+		src = fmt.Sprintf("%s %s", e.Kind, e.Name)
+	}
+	return fmt.Sprintf("%s references unknown template variable(s): %s", src, strings.Join(e.Variables, ", "))
+}
+
+// RenderTemplate replaces the `${...}` placeholders in the supplied JSON data with the supplied variables.
+// The values are JSON escaped, so the placeholders must be within JSON strings.
+// An error is returned, listing the variables, if any placeholders do not have a value.
+func RenderTemplate(data []byte, vars TemplateVariables) ([]byte, error) {
+	vars = vars.withDerivedValues()
+	unknown := make(map[string]struct{})
+	result := templatePlaceholderRegex.ReplaceAllFunc(data, func(match []byte) []byte {
+		name := string(templatePlaceholderRegex.FindSubmatch(match)[1])
+		v, ok := vars[name]
+		if !ok {
+			unknown[name] = struct{}{}
+			return match
+		}
+		escaped, _ := json.Marshal(v)
+		return escaped[1 : len(escaped)-1]
+	})
+	if len(unknown) > 0 {
+		names := make([]string, 0, len(unknown))
+		for n := range unknown {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, &TemplateError{Variables: names}
+	}
+	return result, nil
+}
+
+// RenderPolicyDefinition returns a copy of the supplied policy definition with the placeholders rendered
+func (az *AlzLib) RenderPolicyDefinition(pd armpolicy.Definition, vars TemplateVariables) (*armpolicy.Definition, error) {
+	return renderObject(az, KindPolicyDefinition, pd.Name, &pd, vars)
+}
+
+// RenderPolicySetDefinition returns a copy of the supplied policy set definition with the placeholders rendered
+func (az *AlzLib) RenderPolicySetDefinition(psd armpolicy.SetDefinition, vars TemplateVariables) (*armpolicy.SetDefinition, error) {
+	return renderObject(az, KindPolicySetDefinition, psd.Name, &psd, vars)
+}
+
+// RenderPolicyAssignment returns a copy of the supplied policy assignment with the placeholders rendered
+func (az *AlzLib) RenderPolicyAssignment(pa armpolicy.Assignment, vars TemplateVariables) (*armpolicy.Assignment, error) {
+	return renderObject(az, KindPolicyAssignment, pa.Name, &pa, vars)
+}
+
+// RenderRoleDefinition returns a copy of the supplied role definition with the placeholders rendered
+func (az *AlzLib) RenderRoleDefinition(rd RoleDefinition, vars TemplateVariables) (*RoleDefinition, error) {
+	var name *string
+	if rd.Properties != nil {
+		name = rd.Properties.RoleName
+	}
+	return renderObject(az, KindRoleDefinition, name, &rd, vars)
+}
+
+// renderObject marshals the supplied object to JSON, renders the placeholders, and unmarshals the result into a new object.
+// Template errors are annotated with the object and the lib file it was read from.
+func renderObject[T any](az *AlzLib, kind ObjectKind, name *string, in *T, vars TemplateVariables) (*T, error) {
+	n := ""
+	if name != nil {
+		n = *name
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling %s %s: %s", kind, n, err)
+	}
+	rendered, err := RenderTemplate(data, vars)
+	if err != nil {
+		if te, ok := err.(*TemplateError); ok {
+			te.Kind = kind
+			te.Name = n
+			te.File = az.SourceFile(kind, n)
+		}
+		return nil, err
+	}
+	out := new(T)
+	if err := json.Unmarshal(rendered, out); err != nil {
+		return nil, fmt.Errorf("error unmarshalling rendered %s %s: %s", kind, n, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package alzlib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestRenderTemplate tests that the placeholders are replaced with the supplied values
+func TestRenderTemplate(t *testing.T) {
+	data := []byte(`{"scope": "${current_scope_resource_id}", "name": "${root_scope_id}-la"}`)
+	vars := TemplateVariables{
+		TemplateVariableRootScopeId:            "alz",
+		TemplateVariableCurrentScopeResourceId: "/providers/Microsoft.Management/managementGroups/alz-corp",
+	}
+	result, err := RenderTemplate(data, vars)
+	assert.NilError(t, err)
+	assert.Equal(t, string(result), `{"scope": "/providers/Microsoft.Management/managementGroups/alz-corp", "name": "alz-la"}`)
+}
+
+// TestRenderTemplateEscapesValues tests that values containing JSON special characters
+// produce valid JSON
+func TestRenderTemplateEscapesValues(t *testing.T) {
+	data := []byte(`{"location": "${default_location}"}`)
+	result, err := RenderTemplate(data, TemplateVariables{TemplateVariableDefaultLocation: `uk"south`})
+	assert.NilError(t, err)
+	assert.Equal(t, string(result), `{"location": "uk\"south"}`)
+}
+
+// TestRenderTemplateDerivedValues tests that the resource ids are derived from the scope ids
+// when they are not explicitly supplied
+func TestRenderTemplateDerivedValues(t *testing.T) {
+	data := []byte(`["${root_scope_resource_id}", "${current_scope_resource_id}"]`)
+	vars := TemplateVariables{
+		TemplateVariableRootScopeId:    "alz",
+		TemplateVariableCurrentScopeId: "alz-corp",
+	}
+	result, err := RenderTemplate(data, vars)
+	assert.NilError(t, err)
+	assert.Equal(t, string(result), `["/providers/Microsoft.Management/managementGroups/alz", "/providers/Microsoft.Management/managementGroups/alz-corp"]`)
+}
+
+// TestRenderTemplateUnknownVariables tests that all unknown variables are listed in the error
+func TestRenderTemplateUnknownVariables(t *testing.T) {
+	data := []byte(`["${zzz}", "${default_location}", "${aaa}", "${zzz}"]`)
+	_, err := RenderTemplate(data, TemplateVariables{TemplateVariableDefaultLocation: "uksouth"})
+	te, ok := err.(*TemplateError)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, te.Variables, []string{"aaa", "zzz"})
+}
+
+// TestWithScope tests that the current scope variables are set for the management group
+func TestWithScope(t *testing.T) {
+	vars := TemplateVariables{TemplateVariableRootScopeId: "alz"}
+	scoped := vars.WithScope("alz-corp")
+	assert.Equal(t, scoped[TemplateVariableCurrentScopeId], "alz-corp")
+	assert.Equal(t, scoped[TemplateVariableCurrentScopeResourceId], "/providers/Microsoft.Management/managementGroups/alz-corp")
+	assert.Equal(t, scoped[TemplateVariableRootScopeId], "alz")
+	_, exists := vars[TemplateVariableCurrentScopeId]
+	assert.Assert(t, !exists, "WithScope should not modify the receiver")
+}
+
+// TestRenderPolicyAssignment tests the rendering of a policy assignment from the test library
+func TestRenderPolicyAssignment(t *testing.T) {
+	az, err := NewAlzLib(testLibDir)
+	assert.NilError(t, err)
+	vars := TemplateVariables{
+		TemplateVariableRootScopeId:     "alz",
+		TemplateVariableCurrentScopeId:  "alz-corp",
+		TemplateVariableDefaultLocation: "uksouth",
+	}
+	pa, err := az.RenderPolicyAssignment(*az.PolicyAssignments["Deny-Resource-Locations"], vars)
+	assert.NilError(t, err)
+	assert.Equal(t, *pa.Location, "uksouth")
+	assert.Equal(t, *pa.Properties.Scope, "/providers/Microsoft.Management/managementGroups/alz-corp")
+	assert.Equal(t, *az.PolicyAssignments["Deny-Resource-Locations"].Location, "${default_location}")
+}
+
+// TestRenderPolicyAssignmentUnknownVariable tests that the template error names the source file
+func TestRenderPolicyAssignmentUnknownVariable(t *testing.T) {
+	az, err := NewAlzLib(testLibDir)
+	assert.NilError(t, err)
+	_, err = az.RenderPolicyAssignment(*az.PolicyAssignments["Deny-Resource-Locations"], TemplateVariables{})
+	te, ok := err.(*TemplateError)
+	assert.Assert(t, ok)
+	assert.Equal(t, te.Kind, KindPolicyAssignment)
+	assert.Equal(t, filepath.Base(te.File), "policy_assignment_es_deny_resource_locations.tmpl.json")
+	assert.DeepEqual(t, te.Variables, []string{"current_scope_resource_id", "default_location"})
+}
@@ -0,0 +1,175 @@
+package alzlib
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+)
+
+// libFileProcessor processes one kind of lib file.
+// Parsing does not modify the AlzLib, so that lib files can be parsed concurrently,
+// the parsed objects are then added to the AlzLib in the order that the files were walked.
+type libFileProcessor struct {
+	// parse returns the object declared in the lib file bytes
+	parse func(data []byte) (interface{}, error)
+	// decode returns the object from its JSON encoding in the parse cache
+	decode func(data []byte) (interface{}, error)
+	// add adds the parsed object, which was read from the lib file with the supplied path, to the AlzLib
+	add func(az *AlzLib, path string, obj interface{}) error
+}
+
+// ObjectKind is the kind of object that is declared in a lib file
+type ObjectKind string
+
+// These are the kinds of library object that are tracked by the AlzLib
+const (
+	KindArchetypeDefinition ObjectKind = "archetype_definition"
+	KindArchetypeExclusion  ObjectKind = "archetype_exclusion"
+	KindArchetypeExtension  ObjectKind = "archetype_extension"
+	KindPolicyAssignment    ObjectKind = "policy_assignment"
+	KindPolicyDefinition    ObjectKind = "policy_definition"
+	KindPolicySetDefinition ObjectKind = "policy_set_definition"
+	KindRoleDefinition      ObjectKind = "role_definition"
+)
+
+// description returns the text used to describe the kind of object in error messages
+func (k ObjectKind) description() string {
+	switch k {
+	case KindArchetypeDefinition:
+		return "archetype id"
+	default:
+		return strings.ReplaceAll(string(k), "_", " ") + " name"
+	}
+}
+
+// namePointer returns the JSON pointer to the name of the object in its lib file
+func (k ObjectKind) namePointer(name string) string {
+	switch k {
+	case KindArchetypeDefinition:
+		return jsonPointer(name)
+	case KindRoleDefinition:
+		return "/properties/roleName"
+	default:
+		return "/name"
+	}
+}
+
+// objectKey uniquely identifies a library object by its kind and name
+type objectKey struct {
+	kind ObjectKind
+	name string
+}
+
+// ObjectSource describes where a library object was read from
+type ObjectSource struct {
+	// File is the path of the lib file
+	File string
+	// Layer is the index of the library layer, starting at 0
+	Layer int
+	// LayerPath is the directory of the library layer
+	LayerPath string
+	// Replaces is the path of the lib file in an earlier layer that declared the object,
+	// which the object replaces. It is empty if the object was not declared in an earlier layer.
+	Replaces string
+}
+
+// LibFile is a lib file that has been processed
+type LibFile struct {
+	// Layer is the index of the library layer, starting at 0
+	Layer int
+	// Path is the slash separated path of the file within the layer
+	Path string
+	// Kind is the kind of object that is declared in the file
+	Kind ObjectKind
+	// Name is the name of the object that is declared in the file
+	Name string
+	// Sha256 is the hex encoded SHA-256 of the file contents
+	Sha256 string
+}
+
+// AlzLib is the structure that gets built from the the library files
+// do not create this directly, use NewAlzLib instead.
+type AlzLib struct {
+	Archetypes           map[string]*ArchetypeDefinition
+	PolicyDefinitions    map[string]*armpolicy.Definition
+	PolicySetDefinitions map[string]*armpolicy.SetDefinition
+	PolicyAssignments    map[string]*armpolicy.Assignment
+	RoleDefinitions      map[string]*RoleDefinition
+	// These are not exported and only used on the initial load
+	libArchetypeDefinitions []*LibArchetypeDefinition
+	libArchetypeExtensions  []*LibArchetypeDefinition
+	libArchetypeExclusions  []*LibArchetypeDefinition
+	// sources records the file and layer that each library object was read from
+	sources map[objectKey]ObjectSource
+	// layers are the library directories, in the order that they are processed
+	layers []string
+	// layer is the index of the layer that is currently being processed
+	layer int
+	// files are the lib files that have been processed, in the order that they were processed
+	files []LibFile
+	// ignoredFiles are the paths of the JSON files that were not processed, as they do not have a lib file prefix
+	ignoredFiles []string
+	// catalog is the built-in policy catalog, it is nil if there is none
+	catalog *BuiltInCatalog
+	// members are the resolved members of the policy set definitions, keyed by policy set definition name
+	members map[string][]PolicySetMember
+}
+
+// ArchetypeDefinition represents an archetype definition that hasn't been assigned to a management group
+// maps contain values, rather than pointers, because we don't want to modify the original
+type ArchetypeDefinition struct {
+	AlzLib               *AlzLib
+	PolicyDefinitions    map[string]armpolicy.Definition
+	PolicyAssignments    map[string]armpolicy.Assignment
+	PolicySetDefinitions map[string]armpolicy.SetDefinition
+	RoleDefinitions      map[string]RoleDefinition
+	RoleAssignments      map[string]RoleAssignment
+}
+
+// LibArchetypeDefinition represents an archetype definition file,
+// it used to construct the Archetype struct and is then added to the AlzLib struct
+type LibArchetypeDefinition struct {
+	Id                   string
+	Config               *libArchetypeDefinitionConfig `json:"archetype_config"`
+	PolicyAssignments    []string                      `json:"policy_assignments"`
+	PolicyDefinitions    []string                      `json:"policy_definitions"`
+	PolicySetDefinitions []string                      `json:"policy_set_definitions"`
+	RoleDefinitions      []string                      `json:"role_definitions"`
+	// file and pointer locate the definition in its lib file, they are used to report problems with it
+	file    string
+	pointer string
+}
+
+// libArchetypeConfig is a representation of the archetype_config parameters
+// that are used in the archetype definition files.
+// .
+// They override any paremeters defined in the policy assignment files.
+type libArchetypeDefinitionConfig struct {
+	Parameters    map[string]interface{} `json:"parameters"`
+	AccessControl map[string]interface{} `json:"access_control"`
+}
+
+// RoleDefinition represents a custom role definition lib file.
+// The fields follow the ARM representation of Microsoft.Authorization/roleDefinitions.
+type RoleDefinition struct {
+	Name       *string                   `json:"name,omitempty"`
+	Type       *string                   `json:"type,omitempty"`
+	Properties *RoleDefinitionProperties `json:"properties,omitempty"`
+}
+
+// RoleDefinitionProperties are the properties of a role definition
+type RoleDefinitionProperties struct {
+	RoleName         *string       `json:"roleName,omitempty"`
+	Description      *string       `json:"description,omitempty"`
+	RoleType         *string       `json:"type,omitempty"`
+	Permissions      []*Permission `json:"permissions,omitempty"`
+	AssignableScopes []*string     `json:"assignableScopes,omitempty"`
+}
+
+// Permission is a set of actions that are allowed, or not allowed, by a role definition
+type Permission struct {
+	Actions        []*string `json:"actions,omitempty"`
+	NotActions     []*string `json:"notActions,omitempty"`
+	DataActions    []*string `json:"dataActions,omitempty"`
+	NotDataActions []*string `json:"notDataActions,omitempty"`
+}
@@ -0,0 +1,106 @@
+package alzlib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Finding is a problem with the library that was found while it was loaded
+type Finding struct {
+	// File is the path of the lib file that has the problem.
+	// It is empty if the problem is not in a lib file.
+	File string
+	// Pointer is the JSON pointer (RFC 6901) to the value in the lib file that has the problem.
+	// It is empty if the problem is with the whole file.
+	Pointer string
+	// Message describes the problem
+	Message string
+	// Warning is true if the problem does not stop the library from being used, e.g. a deprecated policy definition
+	Warning bool
+}
+
+// Error returns the finding as an error message, prefixed with the file and pointer if they are known
+func (f Finding) Error() string {
+	switch {
+	case f.File == "":
+		return f.Message
+	case f.Pointer == "":
+		return fmt.Sprintf("error processing file %s: %s", f.File, f.Message)
+	default:
+		return fmt.Sprintf("error processing file %s at %s: %s", f.File, f.Pointer, f.Message)
+	}
+}
+
+// ValidationError is returned when the library has problems, it lists every problem that was found
+type ValidationError struct {
+	Findings []Finding
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Findings))
+	for i, f := range e.Findings {
+		msgs[i] = f.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// onlyErrors returns the findings that are not warnings
+func onlyErrors(findings []Finding) []Finding {
+	var result []Finding
+	for _, f := range findings {
+		if !f.Warning {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// findingsError returns a ValidationError for the supplied findings, or nil if there are none
+func findingsError(findings []Finding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	return &ValidationError{Findings: findings}
+}
+
+// errorFindings returns the findings in the supplied error.
+// A ValidationError is returned as its findings, any other error is returned as a single finding in the supplied file.
+func errorFindings(err error, file, pointer string) []Finding {
+	if verr, ok := err.(*ValidationError); ok {
+		return verr.Findings
+	}
+	if perr, ok := err.(*pointerError); ok {
+		pointer += perr.pointer
+		err = perr.err
+	}
+	return []Finding{{File: file, Pointer: pointer, Message: err.Error()}}
+}
+
+// pointerError is an error with a value in a lib file, the pointer is relative to the object in the file
+type pointerError struct {
+	pointer string
+	err     error
+}
+
+func (e *pointerError) Error() string {
+	return e.err.Error()
+}
+
+// errorAt returns an error with the value at the supplied JSON pointer in the lib file
+func errorAt(pointer string, format string, a ...interface{}) error {
+	return &pointerError{pointer: pointer, err: fmt.Errorf(format, a...)}
+}
+
+// jsonPointer returns the JSON pointer made of the supplied reference tokens,
+// which are escaped as described in RFC 6901
+func jsonPointer(tokens ...interface{}) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		s := fmt.Sprint(t)
+		s = strings.ReplaceAll(s, "~", "~0")
+		s = strings.ReplaceAll(s, "/", "~1")
+		sb.WriteString("/")
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
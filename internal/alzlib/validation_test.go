@@ -0,0 +1,75 @@
+package alzlib
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestValidateLayers tests that every problem in the library is reported, with its file and JSON pointer
+func TestValidateLayers(t *testing.T) {
+	l, err := DirLayer("./testdata/badlib-validation")
+	assert.NilError(t, err)
+	az, findings, err := ValidateLayers(nil, l)
+	assert.NilError(t, err)
+
+	dir := "testdata/badlib-validation/"
+	assert.DeepEqual(t, findings, []Finding{
+		{
+			File:    dir + "archetype_definition_not_object.json",
+			Pointer: "/not_object",
+			Message: "error processing archetype definition: archetype not_object must be a JSON object",
+		},
+		{
+			File:    dir + "policy_definition_test_policy_duplicate.json",
+			Pointer: "/name",
+			Message: "duplicate policy definition name: test-policy, declared in files " + dir + "policy_definition_test_policy.json and " + dir + "policy_definition_test_policy_duplicate.json",
+		},
+		{
+			File:    dir + "policy_definition_unparseable.json",
+			Message: "error unmarshalling policy definition: unexpected end of JSON input",
+		},
+		{
+			File:    dir + "archetype_definition_invalid.json",
+			Pointer: "/invalid/policy_definitions/1",
+			Message: "policy definition missing-policy not found for archetype invalid",
+		},
+		{
+			File:    dir + "archetype_definition_invalid.json",
+			Pointer: "/invalid/policy_assignments/1",
+			Message: "policy assignment missing-assignment not found for archetype invalid",
+		},
+		{
+			File:    dir + "archetype_definition_invalid.json",
+			Pointer: "/invalid/archetype_config/parameters/test-assignment/notAParameter",
+			Message: "archetype_config.parameters error: cannot modify policy parameter notAParameter in assignment test-assignment, in archetype invalid. parameter notAParameter does not exist",
+		},
+		{
+			File:    dir + "archetype_extension_missing.json",
+			Pointer: "/extend_missing",
+			Message: "cannot extend archetype missing as it does not exist",
+		},
+	})
+
+	// the valid parts of the library are still loaded
+	assert.Equal(t, len(az.Archetypes["invalid"].PolicyDefinitions), 1)
+	assert.Equal(t, az.Archetypes["invalid"].PolicyAssignments["test-assignment"].Properties.Parameters["effect"].Value, "Deny")
+
+	_, err = NewAlzLibFromLayers(l)
+	verr, ok := err.(*ValidationError)
+	assert.Assert(t, ok, err)
+	assert.DeepEqual(t, verr.Findings, findings)
+}
+
+// TestFindingError tests the error message of a finding
+func TestFindingError(t *testing.T) {
+	assert.Equal(t, Finding{Message: "problem"}.Error(), "problem")
+	assert.Equal(t, Finding{File: "a.json", Message: "problem"}.Error(), "error processing file a.json: problem")
+	assert.Equal(t, Finding{File: "a.json", Pointer: "/a", Message: "problem"}.Error(), "error processing file a.json at /a: problem")
+}
+
+// TestJsonPointer tests that the reference tokens are escaped
+func TestJsonPointer(t *testing.T) {
+	assert.Equal(t, jsonPointer("a/b", "c~d", 0), "/a~1b/c~0d/0")
+	assert.Equal(t, jsonPointer(), "")
+}
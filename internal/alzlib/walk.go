@@ -0,0 +1,239 @@
+package alzlib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// WalkOptions control which files in a library layer are read.
+// The zero value reads every file, except those in hidden directories, and does not follow symlinks to directories.
+type WalkOptions struct {
+	// Include are glob patterns of the files to read, if it is empty all files are read
+	Include []string
+	// Exclude are glob patterns of the files and directories that are not read, they take precedence over Include
+	Exclude []string
+	// IncludeHidden reads the files in directories whose names start with a dot
+	IncludeHidden bool
+	// FollowSymlinks reads the files in directories that are symlinks, directories that have already been walked are skipped
+	FollowSymlinks bool
+	// Log, if set, is called with the path of each file and directory that is processed or skipped, and its classification
+	Log func(path, classification string)
+}
+
+// log calls the Log function of the options, if it is set
+func (o WalkOptions) log(p, classification string) {
+	if o.Log != nil {
+		o.Log(p, classification)
+	}
+}
+
+// ValidateGlob returns an error if the supplied pattern is not a valid glob pattern.
+// The patterns use the syntax of path.Match, with / as the separator, and ** matches any number of directories.
+func ValidateGlob(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("glob pattern must not be empty")
+	}
+	for _, seg := range strings.Split(pattern, "/") {
+		if seg == "**" {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %s: %s", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchGlob returns true if the supplied slash separated path matches the glob pattern.
+// Patterns without a / are matched against the base name of the path,
+// otherwise they are matched against the whole path, where ** matches zero or more directories.
+func matchGlob(pattern, p string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(p))
+		return ok
+	}
+	return matchSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(p, "/"))
+}
+
+// matchSegments matches the path segments against the pattern segments
+func matchSegments(pattern, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pattern[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+			return false
+		}
+		pattern, segs = pattern[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// matchAnyGlob returns true if the path matches any of the glob patterns
+func matchAnyGlob(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkLayer walks the file system of the layer and calls fn with the path of each file that is selected by the walk options.
+// Problems walking the file system are returned as findings.
+func walkLayer(l LibLayer, fn func(p string)) ([]Finding, error) {
+	w := &layerWalker{layer: l, fn: fn}
+	if err := w.walk("."); err != nil {
+		return nil, err
+	}
+	return w.findings, nil
+}
+
+// HashLayer returns the hex encoded SHA-256 of each file in the layer that is selected by its walk options, keyed by slash separated path.
+// The layer is walked in the same way as when the library is loaded, so these are the files that can be loaded.
+func HashLayer(l LibLayer) (map[string]string, error) {
+	hashes := make(map[string]string)
+	var readErr error
+	findings, err := walkLayer(l, func(p string) {
+		if readErr != nil {
+			return
+		}
+		data, err := fs.ReadFile(l.FS, p)
+		if err != nil {
+			readErr = fmt.Errorf("error reading %s: %s", p, err)
+			return
+		}
+		sum := sha256.Sum256(data)
+		hashes[p] = hex.EncodeToString(sum[:])
+	})
+	if err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	if err := findingsError(findings); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// layerWalker holds the state of a walk of a library layer
+type layerWalker struct {
+	layer    LibLayer
+	fn       func(p string)
+	findings []Finding
+	// visited are the directories that have been walked, used to detect symlink loops
+	visited []fs.FileInfo
+}
+
+// walk walks the directory tree at the supplied root
+func (w *layerWalker) walk(root string) error {
+	opts := w.layer.Walk
+	return fs.WalkDir(w.layer.FS, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			w.findings = append(w.findings, Finding{Message: fmt.Sprintf("error walking directory %s: %s", w.layer.Name, err)})
+			return nil
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			return w.walkSymlink(p)
+		}
+
+		if d.IsDir() {
+			if p != root && !w.includeDir(p) {
+				return fs.SkipDir
+			}
+			if w.seen(p) {
+				opts.log(p, "skipped, directory has already been walked")
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		w.walkFile(p)
+		return nil
+	})
+}
+
+// walkSymlink processes a symlink, symlinks to files are read and symlinks to directories are only walked if FollowSymlinks is set
+func (w *layerWalker) walkSymlink(p string) error {
+	opts := w.layer.Walk
+	fi, err := fs.Stat(w.layer.FS, p)
+	if err != nil {
+		w.findings = append(w.findings, Finding{Message: fmt.Sprintf("error walking directory %s: %s", w.layer.Name, err)})
+		return nil
+	}
+	if !fi.IsDir() {
+		w.walkFile(p)
+		return nil
+	}
+	if !opts.FollowSymlinks {
+		opts.log(p, "skipped, symlink to a directory")
+		return nil
+	}
+	if !w.includeDir(p) {
+		return nil
+	}
+	return w.walk(p)
+}
+
+// walkFile calls the walk function with the file, if it is selected by the include and exclude patterns
+func (w *layerWalker) walkFile(p string) {
+	opts := w.layer.Walk
+	if matchAnyGlob(opts.Exclude, p) {
+		opts.log(p, "skipped, excluded")
+		return
+	}
+	if len(opts.Include) > 0 && !matchAnyGlob(opts.Include, p) {
+		opts.log(p, "skipped, not included")
+		return
+	}
+	w.fn(p)
+}
+
+// includeDir returns false, and logs the reason, if the directory should not be walked
+func (w *layerWalker) includeDir(p string) bool {
+	opts := w.layer.Walk
+	if !opts.IncludeHidden && strings.HasPrefix(path.Base(p), ".") {
+		opts.log(p, "skipped, hidden directory")
+		return false
+	}
+	if matchAnyGlob(opts.Exclude, p) {
+		opts.log(p, "skipped, excluded")
+		return false
+	}
+	return true
+}
+
+// seen returns true if the directory has already been walked, following a symlink.
+// It always returns false if symlinks are not followed, as a directory cannot be walked twice.
+func (w *layerWalker) seen(p string) bool {
+	if !w.layer.Walk.FollowSymlinks {
+		return false
+	}
+	fi, err := fs.Stat(w.layer.FS, p)
+	if err != nil {
+		return false
+	}
+	for _, v := range w.visited {
+		if os.SameFile(v, fi) {
+			return true
+		}
+	}
+	w.visited = append(w.visited, fi)
+	return false
+}
@@ -0,0 +1,144 @@
+package alzlib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestMatchGlob tests the glob pattern matching of the walk options
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.json", "policy_definition_a.json", true},
+		{"*.json", "sub/dir/policy_definition_a.json", true},
+		{"*.json", "README.md", false},
+		{"policy_*", "sub/policy_definition_a.json", true},
+		{"sub/*.json", "sub/a.json", true},
+		{"sub/*.json", "sub/dir/a.json", false},
+		{"sub/**/*.json", "sub/a.json", true},
+		{"sub/**/*.json", "sub/dir/deeper/a.json", true},
+		{"**/drafts", "a/b/drafts", true},
+		{"**/drafts", "drafts", true},
+		{"**/drafts/**", "a/drafts/b/c.json", true},
+		{"/sub/a.json", "sub/a.json", true},
+		{"other/**", "sub/a.json", false},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, matchGlob(tc.pattern, tc.path), tc.want, "pattern %s path %s", tc.pattern, tc.path)
+	}
+}
+
+// TestValidateGlob tests that invalid glob patterns are rejected
+func TestValidateGlob(t *testing.T) {
+	assert.NilError(t, ValidateGlob("**/*.json"))
+	assert.ErrorContains(t, ValidateGlob("[a-"), "invalid glob pattern [a-")
+	assert.ErrorContains(t, ValidateGlob(""), "must not be empty")
+}
+
+// walkTree creates the supplied files, and their directories, in a temporary directory
+func walkTree(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		assert.NilError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		assert.NilError(t, os.WriteFile(p, []byte("{}"), 0o644))
+	}
+	return dir
+}
+
+// walkPaths returns the paths that are walked in the directory with the supplied options
+func walkPaths(t *testing.T, dir string, opts WalkOptions) []string {
+	t.Helper()
+	var paths []string
+	findings, err := walkLayer(LibLayer{Name: dir, FS: os.DirFS(dir), Walk: opts}, func(p string) {
+		paths = append(paths, p)
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(findings), 0)
+	return paths
+}
+
+// TestWalkLayerPatterns tests that hidden directories are skipped by default and that the include and exclude patterns are applied
+func TestWalkLayerPatterns(t *testing.T) {
+	dir := walkTree(t,
+		"a.json",
+		"README.md",
+		".git/config.json",
+		"drafts/b.json",
+		"sub/c.json",
+		"sub/drafts/d.json",
+	)
+
+	assert.DeepEqual(t, walkPaths(t, dir, WalkOptions{}), []string{"README.md", "a.json", "drafts/b.json", "sub/c.json", "sub/drafts/d.json"})
+	assert.DeepEqual(t, walkPaths(t, dir, WalkOptions{IncludeHidden: true}), []string{".git/config.json", "README.md", "a.json", "drafts/b.json", "sub/c.json", "sub/drafts/d.json"})
+	assert.DeepEqual(t, walkPaths(t, dir, WalkOptions{Exclude: []string{"drafts"}}), []string{"README.md", "a.json", "sub/c.json"})
+	assert.DeepEqual(t, walkPaths(t, dir, WalkOptions{Include: []string{"sub/**/*.json"}}), []string{"sub/c.json", "sub/drafts/d.json"})
+	assert.DeepEqual(t, walkPaths(t, dir, WalkOptions{Include: []string{"*.json"}, Exclude: []string{"sub/drafts/**"}}), []string{"a.json", "drafts/b.json", "sub/c.json"})
+}
+
+// TestWalkLayerSymlinks tests that symlinks to directories are only followed if enabled, and that symlink loops are detected
+func TestWalkLayerSymlinks(t *testing.T) {
+	dir := walkTree(t, "a.json", "sub/b.json")
+	if err := os.Symlink(filepath.Join(dir, "sub"), filepath.Join(dir, "linked")); err != nil {
+		t.Skipf("symlinks are not supported: %s", err)
+	}
+	assert.NilError(t, os.Symlink(filepath.Join(dir, "a.json"), filepath.Join(dir, "sub", "c.json")))
+	// a loop back to the top of the tree
+	assert.NilError(t, os.Symlink(dir, filepath.Join(dir, "sub", "loop")))
+
+	var logged []string
+	log := func(p, classification string) {
+		logged = append(logged, p+": "+classification)
+	}
+
+	assert.DeepEqual(t, walkPaths(t, dir, WalkOptions{Log: log}), []string{"a.json", "sub/b.json", "sub/c.json"})
+	assert.DeepEqual(t, logged, []string{"linked: skipped, symlink to a directory", "sub/loop: skipped, symlink to a directory"})
+
+	logged = nil
+	// linked is walked before sub, so sub is skipped as it has already been walked
+	assert.DeepEqual(t, walkPaths(t, dir, WalkOptions{FollowSymlinks: true, Log: log}), []string{"a.json", "linked/b.json", "linked/c.json"})
+	assert.DeepEqual(t, logged, []string{"linked/loop: skipped, directory has already been walked", "sub: skipped, directory has already been walked"})
+}
+
+// TestNewAlzLibWalkOptions tests that the walk options of a layer are used when the library is loaded,
+// and that each file is logged with its classification
+func TestNewAlzLibWalkOptions(t *testing.T) {
+	dir := t.TempDir()
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "drafts"), 0o755))
+	writeLibFiles(t, dir, map[string]string{
+		"policy_definition_a.json":        `{"name": "a"}`,
+		"drafts/policy_definition_b.json": `{"name": "b"}`,
+		"notes.txt":                       "notes",
+	})
+
+	logged := make(map[string]string)
+	az, err := NewAlzLibFromLayers(LibLayer{Name: dir, FS: os.DirFS(dir), Walk: WalkOptions{
+		Exclude: []string{"drafts"},
+		Log: func(p, classification string) {
+			logged[p] = classification
+		},
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(az.PolicyDefinitions), 1)
+	assert.DeepEqual(t, logged, map[string]string{
+		"policy_definition_a.json": string(KindPolicyDefinition),
+		"drafts":                   "skipped, excluded",
+		"notes.txt":                "ignored, not a lib file",
+	})
+}
+
+// TestHashLayer tests that the files that are hashed are the files that are walked
+func TestHashLayer(t *testing.T) {
+	dir := walkTree(t, "a.json", ".git/config.json", "drafts/b.json")
+	hashes, err := HashLayer(LibLayer{Name: dir, FS: os.DirFS(dir), Walk: WalkOptions{Exclude: []string{"drafts"}}})
+	assert.NilError(t, err)
+	// the SHA-256 of {}
+	assert.DeepEqual(t, hashes, map[string]string{"a.json": "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"})
+}
@@ -0,0 +1,189 @@
+// Package bundle creates and verifies signed library bundles.
+//
+// A bundle is a library with a manifest, listing the SHA-256 of each file, and a detached ed25519 signature of the manifest.
+// Both are stored in the root of the library.
+package bundle
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// These are the names of the bundle files in the root of the library
+const ManifestFileName = "alzlib-manifest.json"
+const SignatureFileName = "alzlib-manifest.json.sig"
+
+// manifestVersion is the version of the manifest format
+const manifestVersion = 1
+
+// Manifest lists the files in a bundle with their SHA-256
+type Manifest struct {
+	Version int            `json:"version"`
+	Files   []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file in the manifest, the path is slash separated and relative to the root of the library
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Sha256 string `json:"sha256"`
+}
+
+// Pack creates the manifest of the supplied files, keyed by slash separated path with their hex encoded SHA-256,
+// apart from the bundle files. Use alzlib.HashLayer to hash the files of a library,
+// so that the manifest lists the files that are read when the library is loaded.
+func Pack(hashes map[string]string) *Manifest {
+	m := &Manifest{
+		Version: manifestVersion,
+		Files:   make([]ManifestFile, 0, len(hashes)),
+	}
+	for p, h := range hashes {
+		if isBundleFile(p) {
+			continue
+		}
+		m.Files = append(m.Files, ManifestFile{Path: p, Sha256: h})
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return m
+}
+
+// isBundleFile returns true if the path is one of the bundle files in the root of the library
+func isBundleFile(p string) bool {
+	return p == ManifestFileName || p == SignatureFileName
+}
+
+// Marshal returns the JSON encoding of the manifest, which is the data that is signed
+func (m *Manifest) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Sign returns the base64 encoded detached signature of the supplied manifest data
+func Sign(manifest []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest)) + "\n")
+}
+
+// GenerateKey returns a new base64 encoded ed25519 public and private key pair
+func GenerateKey() (string, string, error) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// ParsePublicKey parses a base64 encoded ed25519 public key
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key %q, it must be a base64 encoded %d byte ed25519 public key", s, ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ParsePrivateKey parses a base64 encoded ed25519 private key
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key, it must be a base64 encoded %d byte ed25519 private key", ed25519.PrivateKeySize)
+	}
+	return ed25519.PrivateKey(b), nil
+}
+
+// VerificationError lists the differences between a library and its manifest
+type VerificationError struct {
+	Unsigned []string
+	Changed  []string
+	Missing  []string
+}
+
+// Error implements the error interface
+func (e *VerificationError) Error() string {
+	msgs := make([]string, 0, 3)
+	if len(e.Unsigned) > 0 {
+		msgs = append(msgs, fmt.Sprintf("files not in the manifest: %s", strings.Join(e.Unsigned, ", ")))
+	}
+	if len(e.Changed) > 0 {
+		msgs = append(msgs, fmt.Sprintf("files changed since the manifest was signed: %s", strings.Join(e.Changed, ", ")))
+	}
+	if len(e.Missing) > 0 {
+		msgs = append(msgs, fmt.Sprintf("files in the manifest that are missing: %s", strings.Join(e.Missing, ", ")))
+	}
+	return "the library does not match its manifest. " + strings.Join(msgs, ". ")
+}
+
+// ReadManifest reads the manifest of the library in the supplied file system,
+// and checks that it is signed by one of the trusted keys.
+// The files are not checked, as they can change before they are read, use Verify with the hashes of the files that were read.
+func ReadManifest(fsys fs.FS, trustedKeys []ed25519.PublicKey) (*Manifest, error) {
+	data, err := fs.ReadFile(fsys, ManifestFileName)
+	if err != nil {
+		return nil, fmt.Errorf("the library is not a signed bundle, unable to read %s: %s", ManifestFileName, err)
+	}
+	sig, err := fs.ReadFile(fsys, SignatureFileName)
+	if err != nil {
+		return nil, fmt.Errorf("the library is not a signed bundle, unable to read %s: %s", SignatureFileName, err)
+	}
+	rawSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature in %s: %s", SignatureFileName, err)
+	}
+
+	trusted := false
+	for _, k := range trustedKeys {
+		if ed25519.Verify(k, data, rawSig) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return nil, fmt.Errorf("the signature in %s is not from a trusted key", SignatureFileName)
+	}
+
+	m := new(Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %s", ManifestFileName, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d in %s", m.Version, ManifestFileName)
+	}
+	return m, nil
+}
+
+// Verify checks that the supplied files, keyed by slash separated path with their hex encoded SHA-256, are exactly the files in the manifest.
+// Only the files in the manifest that are selected by the filter are expected, e.g. the lib files when the hashes are of the lib files that were loaded.
+// A nil filter expects all of the files in the manifest.
+func (m *Manifest) Verify(hashes map[string]string, filter func(p string) bool) error {
+	unchecked := make(map[string]string, len(hashes))
+	for p, h := range hashes {
+		if !isBundleFile(p) {
+			unchecked[p] = h
+		}
+	}
+	verr := new(VerificationError)
+	for _, f := range m.Files {
+		h, ok := unchecked[f.Path]
+		switch {
+		case ok && h != f.Sha256:
+			verr.Changed = append(verr.Changed, f.Path)
+		case !ok && (filter == nil || filter(f.Path)):
+			verr.Missing = append(verr.Missing, f.Path)
+		}
+		delete(unchecked, f.Path)
+	}
+	for p := range unchecked {
+		verr.Unsigned = append(verr.Unsigned, p)
+	}
+	sort.Strings(verr.Unsigned)
+	if len(verr.Unsigned)+len(verr.Changed)+len(verr.Missing) > 0 {
+		return verr
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestVerify tests that a signed bundle is verified with a trusted key
+func TestVerify(t *testing.T) {
+	dir, pub := newTestBundle(t)
+	m, err := ReadManifest(os.DirFS(dir), []ed25519.PublicKey{pub})
+	assert.NilError(t, err)
+	assert.Equal(t, len(m.Files), 3)
+	assert.NilError(t, m.Verify(hashDir(t, dir), nil))
+}
+
+// TestVerifyUntrustedKey tests that a bundle signed with another key is rejected
+func TestVerifyUntrustedKey(t *testing.T) {
+	dir, _ := newTestBundle(t)
+	other, _, err := ed25519.GenerateKey(nil)
+	assert.NilError(t, err)
+	_, err = ReadManifest(os.DirFS(dir), []ed25519.PublicKey{other})
+	assert.ErrorContains(t, err, "is not from a trusted key")
+}
+
+// TestVerifyUnsigned tests that a library without a manifest is rejected
+func TestVerifyUnsigned(t *testing.T) {
+	_, pub := newTestBundle(t)
+	_, err := ReadManifest(os.DirFS("../alzlib/testdata/layers/base"), []ed25519.PublicKey{pub})
+	assert.ErrorContains(t, err, "the library is not a signed bundle")
+}
+
+// TestVerifyModified tests that unsigned, changed and missing files are all reported
+func TestVerifyModified(t *testing.T) {
+	dir, pub := newTestBundle(t)
+	m, err := ReadManifest(os.DirFS(dir), []ed25519.PublicKey{pub})
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "policy_definition_test_policy.json"), []byte("{}"), 0o644))
+	assert.NilError(t, os.Remove(filepath.Join(dir, "policy_definition_test_policy2.json")))
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, "extra"), 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "extra", "policy_definition_extra.json"), []byte("{}"), 0o644))
+
+	err = m.Verify(hashDir(t, dir), nil)
+	verr, ok := err.(*VerificationError)
+	assert.Assert(t, ok, err)
+	assert.DeepEqual(t, verr.Unsigned, []string{"extra/policy_definition_extra.json"})
+	assert.DeepEqual(t, verr.Changed, []string{"policy_definition_test_policy.json"})
+	assert.DeepEqual(t, verr.Missing, []string{"policy_definition_test_policy2.json"})
+}
+
+// TestVerifyFilter tests that only the files in the manifest selected by the filter are expected,
+// so that the files that are not loaded, e.g. a README, do not need to be supplied
+func TestVerifyFilter(t *testing.T) {
+	m := Pack(map[string]string{
+		"README.md":              "1",
+		"policy_definition.json": "2",
+		ManifestFileName:         "3",
+	})
+	assert.DeepEqual(t, m.Files, []ManifestFile{{Path: "README.md", Sha256: "1"}, {Path: "policy_definition.json", Sha256: "2"}})
+
+	isJSON := func(p string) bool { return strings.HasSuffix(p, ".json") }
+	assert.NilError(t, m.Verify(map[string]string{"policy_definition.json": "2", SignatureFileName: "4"}, isJSON))
+	err := m.Verify(map[string]string{}, isJSON)
+	verr, ok := err.(*VerificationError)
+	assert.Assert(t, ok, err)
+	assert.DeepEqual(t, verr.Missing, []string{"policy_definition.json"})
+}
+
+// TestParseKeys tests that the generated keys can be parsed
+func TestParseKeys(t *testing.T) {
+	pub, priv, err := GenerateKey()
+	assert.NilError(t, err)
+	_, err = ParsePublicKey(pub)
+	assert.NilError(t, err)
+	_, err = ParsePrivateKey(priv)
+	assert.NilError(t, err)
+	_, err = ParsePublicKey(priv)
+	assert.ErrorContains(t, err, "invalid public key")
+}
+
+// hashDir returns the SHA-256 of each file in the directory, keyed by slash separated path
+func hashDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	hashes := make(map[string]string)
+	fsys := os.DirFS(dir)
+	assert.NilError(t, fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hashes[p] = hex.EncodeToString(sum[:])
+		return nil
+	}))
+	return hashes
+}
+
+// newTestBundle copies the base test layer to a temporary directory and signs it with a new key
+func newTestBundle(t *testing.T) (string, ed25519.PublicKey) {
+	t.Helper()
+	dir := t.TempDir()
+	src := "../alzlib/testdata/layers/base"
+	entries, err := os.ReadDir(src)
+	assert.NilError(t, err)
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(src, e.Name()))
+		assert.NilError(t, err)
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, e.Name()), data, 0o644))
+	}
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NilError(t, err)
+	m := Pack(hashDir(t, dir))
+	data, err := m.Marshal()
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, ManifestFileName), data, 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, SignatureFileName), Sign(data, priv), 0o644))
+	return dir, pub
+}
@@ -0,0 +1,35 @@
+// Package library contains the reference ALZ library that is embedded in the provider binary.
+// It is used when no lib directory is configured.
+package library
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Version is the tag of github.com/matt-FFFFFF/alzlib that the embedded lib files were copied from, as recorded in README.md.
+// It must be updated whenever the contents of the lib directory are changed.
+const Version = "v0.1.2"
+
+// LayerName is the name of the embedded library layer, it is used as the layer of the objects that it contains
+const LayerName = "embedded"
+
+//go:embed lib
+var content embed.FS
+
+// FS returns the file system containing the embedded lib files
+func FS() fs.FS {
+	// the lib directory is always embedded, so this cannot fail
+	sub, _ := fs.Sub(content, "lib")
+	return sub
+}
+
+// Layer returns the embedded library as an alzlib library layer
+func Layer() alzlib.LibLayer {
+	return alzlib.LibLayer{
+		Name: LayerName,
+		FS:   FS(),
+	}
+}
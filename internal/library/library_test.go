@@ -0,0 +1,30 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+	"gotest.tools/v3/assert"
+)
+
+// TestEmbeddedLibrary tests that the embedded library can be loaded
+func TestEmbeddedLibrary(t *testing.T) {
+	az, err := alzlib.NewAlzLibFromLayers(Layer())
+	assert.NilError(t, err)
+	assert.Equal(t, len(az.PolicyDefinitions), 104)
+	assert.Equal(t, len(az.Archetypes), 12)
+	src, ok := az.Source(alzlib.KindPolicyDefinition, "Deny-Subnet-Without-Nsg")
+	assert.Assert(t, ok)
+	assert.Equal(t, src.LayerPath, LayerName)
+	assert.Equal(t, src.File, filepath.FromSlash("embedded/policy_definitions/policy_definition_es_deny_subnet_without_nsg.json"))
+}
+
+// TestVersionRecorded tests that the version is the tag recorded next to the lib files
+func TestVersionRecorded(t *testing.T) {
+	readme, err := os.ReadFile("README.md")
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(readme), "at tag `"+Version+"`"), "README.md does not record version %s", Version)
+}
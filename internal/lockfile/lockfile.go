@@ -0,0 +1,144 @@
+// Package lockfile reads and writes the library lock file,
+// which records the content hash of every lib file that the provider loaded.
+package lockfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// DefaultFileName is the conventional name of the lock file, in the root module directory
+const DefaultFileName = ".alzlib.lock.json"
+
+// lockVersion is the version of the lock file format
+const lockVersion = 1
+
+// Lock is the contents of the lock file
+type Lock struct {
+	Version int    `json:"version"`
+	Files   []File `json:"files"`
+}
+
+// File is a lib file in the lock file
+type File struct {
+	Layer  int    `json:"layer"`
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Sha256 string `json:"sha256"`
+}
+
+// key identifies the file across lock files
+func (f File) key() string {
+	return fmt.Sprintf("%d/%s", f.Layer, f.Path)
+}
+
+// describe returns the text used to describe the file in a diff
+func (f File) describe() string {
+	return fmt.Sprintf("%s %s (layer %d: %s)", strings.ReplaceAll(f.Kind, "_", " "), f.Name, f.Layer, f.Path)
+}
+
+// FromLibrary creates a lock from the lib files that were processed by the supplied AlzLib
+func FromLibrary(az *alzlib.AlzLib) *Lock {
+	files := az.Files()
+	l := &Lock{
+		Version: lockVersion,
+		Files:   make([]File, len(files)),
+	}
+	for i, f := range files {
+		l.Files[i] = File{
+			Layer:  f.Layer,
+			Path:   f.Path,
+			Kind:   string(f.Kind),
+			Name:   f.Name,
+			Sha256: f.Sha256,
+		}
+	}
+	sort.Slice(l.Files, func(i, j int) bool {
+		if l.Files[i].Layer != l.Files[j].Layer {
+			return l.Files[i].Layer < l.Files[j].Layer
+		}
+		return l.Files[i].Path < l.Files[j].Path
+	})
+	return l
+}
+
+// Read reads the lock file, it returns nil if the file does not exist
+func Read(p string) (*Lock, error) {
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading lock file %s: %s", p, err)
+	}
+	l := new(Lock)
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("error reading lock file %s: %s", p, err)
+	}
+	if l.Version != lockVersion {
+		return nil, fmt.Errorf("unsupported version %d in lock file %s", l.Version, p)
+	}
+	return l, nil
+}
+
+// Write writes the lock file
+func (l *Lock) Write(p string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	f, err := os.CreateTemp(filepath.Dir(p), ".alzlib.lock-")
+	if err != nil {
+		return fmt.Errorf("error writing lock file %s: %s", p, err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing lock file %s: %s", p, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing lock file %s: %s", p, err)
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("error writing lock file %s: %s", p, err)
+	}
+	return nil
+}
+
+// Diff returns the differences between the locked files and the supplied lock, one line per file.
+// Each line is prefixed with `+` for added, `-` for removed and `~` for changed files.
+// It returns nil if they are the same.
+func (l *Lock) Diff(current *Lock) []string {
+	locked := make(map[string]File, len(l.Files))
+	for _, f := range l.Files {
+		locked[f.key()] = f
+	}
+
+	var diff []string
+	for _, f := range current.Files {
+		old, ok := locked[f.key()]
+		delete(locked, f.key())
+		switch {
+		case !ok:
+			diff = append(diff, "+ "+f.describe())
+		case old.Sha256 != f.Sha256 || old.Kind != f.Kind || old.Name != f.Name:
+			diff = append(diff, "~ "+f.describe())
+		}
+	}
+	for _, f := range l.Files {
+		if _, ok := locked[f.key()]; ok {
+			diff = append(diff, "- "+f.describe())
+		}
+	}
+	return diff
+}
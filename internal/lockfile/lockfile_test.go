@@ -0,0 +1,70 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+	"gotest.tools/v3/assert"
+)
+
+// TestFromLibrary tests that every processed file is recorded with its kind and object name
+func TestFromLibrary(t *testing.T) {
+	az, err := alzlib.NewAlzLib("../alzlib/testdata/layers/base", "../alzlib/testdata/layers/override")
+	assert.NilError(t, err)
+	l := FromLibrary(az)
+	assert.Equal(t, len(l.Files), 5)
+	assert.DeepEqual(t, l.Files[0], File{
+		Layer:  0,
+		Path:   "archetype_definition_test.json",
+		Kind:   "archetype_definition",
+		Name:   "test",
+		Sha256: l.Files[0].Sha256,
+	})
+	assert.Equal(t, l.Files[len(l.Files)-1].Layer, 1)
+}
+
+// TestReadWrite tests that the lock file is written and read back, and that a missing file is not an error
+func TestReadWrite(t *testing.T) {
+	p := filepath.Join(t.TempDir(), DefaultFileName)
+	l, err := Read(p)
+	assert.NilError(t, err)
+	assert.Assert(t, l == nil)
+
+	az, err := alzlib.NewAlzLib("../alzlib/testdata/layers/base")
+	assert.NilError(t, err)
+	assert.NilError(t, FromLibrary(az).Write(p))
+	l, err = Read(p)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, l, FromLibrary(az))
+	assert.Assert(t, l.Diff(FromLibrary(az)) == nil)
+}
+
+// TestDiff tests that added, removed and changed files are reported
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	src := "../alzlib/testdata/layers/base"
+	entries, err := os.ReadDir(src)
+	assert.NilError(t, err)
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(src, e.Name()))
+		assert.NilError(t, err)
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, e.Name()), data, 0o644))
+	}
+	az, err := alzlib.NewAlzLib(dir)
+	assert.NilError(t, err)
+	locked := FromLibrary(az)
+
+	assert.NilError(t, os.Remove(filepath.Join(dir, "policy_definition_test_policy2.json")))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "archetype_definition_test.json"), []byte(`{"test": {"policy_definitions": ["test-policy"]}}`), 0o644))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "policy_definition_test_policy3.json"), []byte(`{"name": "test-policy3"}`), 0o644))
+	az, err = alzlib.NewAlzLib(dir)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, locked.Diff(FromLibrary(az)), []string{
+		"~ archetype definition test (layer 0: archetype_definition_test.json)",
+		"+ policy definition test-policy3 (layer 0: policy_definition_test_policy3.json)",
+		"- policy definition test-policy2 (layer 0: policy_definition_test_policy2.json)",
+	})
+}
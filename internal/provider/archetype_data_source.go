@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = archetypeDataSourceType{}
+var _ tfsdk.DataSource = archetypeDataSource{}
+
+type archetypeDataSourceType struct{}
+
+func (t archetypeDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	stringSet := types.SetType{ElemType: types.StringType}
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "A single archetype from the library, customised by adding or removing policy and role definitions " +
+			"and policy assignments, and by overriding policy assignment parameters. " +
+			"The changes are made in the same way as the archetype extension and exclusion lib files, " +
+			"and do not affect the archetype in the library.",
+
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "A fingerprint of the customised `archetype`, which changes when its content changes",
+				Type:                types.StringType,
+				Computed:            true,
+			},
+			"base_archetype": {
+				MarkdownDescription: "The name of the library archetype to customise",
+				Required:            true,
+				Type:                types.StringType,
+			},
+			"policy_assignments_to_add": {
+				MarkdownDescription: "The names of library policy assignments to add to the archetype",
+				Optional:            true,
+				Type:                stringSet,
+			},
+			"policy_assignments_to_remove": {
+				MarkdownDescription: "The names of policy assignments to remove from the archetype",
+				Optional:            true,
+				Type:                stringSet,
+			},
+			"policy_definitions_to_add": {
+				MarkdownDescription: "The names of library policy definitions to add to the archetype",
+				Optional:            true,
+				Type:                stringSet,
+			},
+			"policy_definitions_to_remove": {
+				MarkdownDescription: "The names of policy definitions to remove from the archetype",
+				Optional:            true,
+				Type:                stringSet,
+			},
+			"policy_set_definitions_to_add": {
+				MarkdownDescription: "The names of library policy set definitions to add to the archetype",
+				Optional:            true,
+				Type:                stringSet,
+			},
+			"policy_set_definitions_to_remove": {
+				MarkdownDescription: "The names of policy set definitions to remove from the archetype",
+				Optional:            true,
+				Type:                stringSet,
+			},
+			"role_definitions_to_add": {
+				MarkdownDescription: "The names of library role definitions to add to the archetype",
+				Optional:            true,
+				Type:                stringSet,
+			},
+			"role_definitions_to_remove": {
+				MarkdownDescription: "The names of role definitions to remove from the archetype",
+				Optional:            true,
+				Type:                stringSet,
+			},
+			"policy_assignment_parameters": {
+				MarkdownDescription: "Policy assignment parameter values that override those in the lib files, keyed by policy assignment name. " +
+					"Each value is a JSON object of parameter names and values, e.g. `jsonencode({ effect = \"Audit\" })`, " +
+					"in the same format as `archetype_config.parameters`.",
+				Optional: true,
+				Type:     types.MapType{ElemType: types.StringType},
+			},
+			"template_variables": {
+				MarkdownDescription: "Values used to render the `${...}` placeholders in the lib files for this data source, " +
+					"e.g. `current_scope_id`. These are merged over the provider `template_variables`.",
+				Optional: true,
+				Type:     types.MapType{ElemType: types.StringType},
+			},
+			"archetype": {
+				MarkdownDescription: "The resulting archetype",
+				Computed:            true,
+				Type:                archetypeType(),
+			},
+		},
+	}, nil
+}
+
+func (t archetypeDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return archetypeDataSource{
+		provider: provider,
+	}, diags
+}
+
+type archetypeDataSource struct {
+	provider provider
+}
+
+type archetypeDataSourceData struct {
+	Id                           types.String   `tfsdk:"id"`
+	BaseArchetype                types.String   `tfsdk:"base_archetype"`
+	PolicyAssignmentsToAdd       types.Set      `tfsdk:"policy_assignments_to_add"`
+	PolicyAssignmentsToRemove    types.Set      `tfsdk:"policy_assignments_to_remove"`
+	PolicyDefinitionsToAdd       types.Set      `tfsdk:"policy_definitions_to_add"`
+	PolicyDefinitionsToRemove    types.Set      `tfsdk:"policy_definitions_to_remove"`
+	PolicySetDefinitionsToAdd    types.Set      `tfsdk:"policy_set_definitions_to_add"`
+	PolicySetDefinitionsToRemove types.Set      `tfsdk:"policy_set_definitions_to_remove"`
+	RoleDefinitionsToAdd         types.Set      `tfsdk:"role_definitions_to_add"`
+	RoleDefinitionsToRemove      types.Set      `tfsdk:"role_definitions_to_remove"`
+	PolicyAssignmentParameters   types.Map      `tfsdk:"policy_assignment_parameters"`
+	TemplateVariables            types.Map      `tfsdk:"template_variables"`
+	Archetype                    *archetypeData `tfsdk:"archetype"`
+}
+
+func (d archetypeDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	var data archetypeDataSourceData
+
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	dsVars, diags := templateVariablesFromMap(ctx, data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	vars := mergeTemplateVariables(d.provider.templateVariables, dsVars)
+
+	name := data.BaseArchetype.Value
+	if _, ok := d.provider.client.Archetypes[name]; !ok {
+		resp.Diagnostics.AddAttributeError(
+			tftypes.NewAttributePath().WithAttributeName("base_archetype"),
+			"Archetype not found",
+			fmt.Sprintf("The archetype %q was not found in the library.", name),
+		)
+		return
+	}
+
+	o := alzlib.ArchetypeOverride{}
+	sets := map[*[]string]types.Set{
+		&o.PolicyAssignmentsToAdd:       data.PolicyAssignmentsToAdd,
+		&o.PolicyAssignmentsToRemove:    data.PolicyAssignmentsToRemove,
+		&o.PolicyDefinitionsToAdd:       data.PolicyDefinitionsToAdd,
+		&o.PolicyDefinitionsToRemove:    data.PolicyDefinitionsToRemove,
+		&o.PolicySetDefinitionsToAdd:    data.PolicySetDefinitionsToAdd,
+		&o.PolicySetDefinitionsToRemove: data.PolicySetDefinitionsToRemove,
+		&o.RoleDefinitionsToAdd:         data.RoleDefinitionsToAdd,
+		&o.RoleDefinitionsToRemove:      data.RoleDefinitionsToRemove,
+	}
+	for target, set := range sets {
+		*target, diags = stringSetElements(ctx, set)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	o.Parameters, diags = policyAssignmentParametersFromMap(ctx, data.PolicyAssignmentParameters)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ad, err := d.provider.client.OverrideArchetype(name, o)
+	if err != nil {
+		resp.Diagnostics.AddError("Error customising archetype", fmt.Sprintf("Unable to customise archetype %s: %s", name, err))
+		return
+	}
+
+	arch := newArchetypeData(d.provider.client, name, ad, vars, tftypes.NewAttributePath().WithAttributeName("archetype"), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = arch.ContentHash
+	data.Archetype = &arch
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// policyAssignmentParametersFromMap decodes the JSON parameter values in the supplied map,
+// which is keyed by policy assignment name
+func policyAssignmentParametersFromMap(ctx context.Context, m types.Map) (map[string]map[string]interface{}, diag.Diagnostics) {
+	if m.Null || m.Unknown {
+		return nil, nil
+	}
+	raw := make(map[string]string, len(m.Elems))
+	diags := m.ElementsAs(ctx, &raw, false)
+	if diags.HasError() {
+		return nil, diags
+	}
+	result := make(map[string]map[string]interface{}, len(raw))
+	for k, v := range raw {
+		params := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(v), &params); err != nil {
+			diags.AddAttributeError(
+				tftypes.NewAttributePath().WithAttributeName("policy_assignment_parameters").WithElementKeyString(k),
+				"Invalid policy assignment parameters",
+				fmt.Sprintf("The parameters for policy assignment %s must be a JSON object of parameter names and values: %s", k, err),
+			)
+			continue
+		}
+		result[k] = params
+	}
+	return result, diags
+}
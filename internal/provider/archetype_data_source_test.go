@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccArchetypeDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccArchetypeDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("data.alzlib_archetype.test", "id", sha256Regex),
+					resource.TestCheckResourceAttr("data.alzlib_archetype.test", "archetype.policy_assignments.%", "5"),
+					resource.TestCheckNoResourceAttr("data.alzlib_archetype.test", "archetype.policy_assignments.Deny-DataB-Pip.name"),
+					resource.TestCheckResourceAttr("data.alzlib_archetype.test", "archetype.policy_assignments.Deny-Resource-Locations.parameters", `{"listOfAllowedLocations":{"value":["northeurope"]}}`),
+				),
+			},
+			// Error messages from the archetype processing
+			{
+				Config:      testAccArchetypeDataSourceConfigDuplicate,
+				ExpectError: regexp.MustCompile("duplicate policy assignment in archetype es_corp: Deny-DataB-Pip"),
+			},
+		},
+	})
+}
+
+const testAccArchetypeDataSourceConfig = `
+data "alzlib_archetype" "test" {
+  base_archetype               = "es_corp"
+  policy_assignments_to_add    = ["Deny-Resource-Locations"]
+  policy_assignments_to_remove = ["Deny-DataB-Pip"]
+  policy_assignment_parameters = {
+    Deny-Resource-Locations = jsonencode({
+      listOfAllowedLocations = ["northeurope"]
+    })
+  }
+}
+`
+
+const testAccArchetypeDataSourceConfigDuplicate = `
+data "alzlib_archetype" "test" {
+  base_archetype            = "es_corp"
+  policy_assignments_to_add = ["Deny-DataB-Pip"]
+}
+`
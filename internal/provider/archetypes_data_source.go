@@ -1,16 +1,18 @@
 package provider
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces
@@ -22,41 +24,179 @@ type archetypesDataSourceType struct{}
 func (t archetypesDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
 	return tfsdk.Schema{
 		// This description is used by the documentation generator and the language server.
-		MarkdownDescription: "Archetypes data from the provider",
+		MarkdownDescription: "Archetypes data from the provider. " +
+			"To reduce the size of the state, select the archetypes with `names`, leave out the heavy JSON fields with the `include_*` arguments, " +
+			"and set `deduplicate_definitions` to return each definition once, instead of in every archetype that contains it.",
 
 		Attributes: map[string]tfsdk.Attribute{
-			// The 'id' attribute is needed for acceptance testing
 			"id": {
-				Type:     types.Int64Type,
-				Computed: true,
+				MarkdownDescription: "A fingerprint of the `archetypes`, which changes when their content changes",
+				Type:                types.StringType,
+				Computed:            true,
+			},
+			"template_variables": {
+				MarkdownDescription: "Values used to render the `${...}` placeholders in the lib files for this data source, " +
+					"e.g. `current_scope_id`. These are merged over the provider `template_variables`.",
+				Optional: true,
+				Type:     types.MapType{ElemType: types.StringType},
+			},
+			"names": {
+				MarkdownDescription: "The names of the archetypes to return. If not set, all of the archetypes are returned.",
+				Optional:            true,
+				Type:                types.ListType{ElemType: types.StringType},
+			},
+			"include_policy_rules": {
+				MarkdownDescription: "Whether to return the `policy_rule` of the policy definitions. Defaults to `true`.",
+				Optional:            true,
+				Type:                types.BoolType,
+			},
+			"include_metadata": {
+				MarkdownDescription: "Whether to return the `metadata` of the policy definitions and policy set definitions. " +
+					"The `version` and `category` are always returned. Defaults to `true`.",
+				Optional: true,
+				Type:     types.BoolType,
+			},
+			"include_parameters": {
+				MarkdownDescription: "Whether to return the `parameters` declared by the policy definitions and policy set definitions. " +
+					"The parameter values of the policy assignments and of the policy set definition members are always returned. Defaults to `true`.",
+				Optional: true,
+				Type:     types.BoolType,
+			},
+			"deduplicate_definitions": {
+				MarkdownDescription: "Whether to return the policy definitions, policy set definitions and role definitions once, " +
+					"in the top level `policy_definitions`, `policy_set_definitions` and `role_definitions`, " +
+					"instead of in each archetype. The archetypes refer to them by name, in their `*_names` attributes. Defaults to `false`.",
+				Optional: true,
+				Type:     types.BoolType,
 			},
 			"archetypes": {
+				MarkdownDescription: "The archetypes, keyed by name. " +
+					"If `deduplicate_definitions` is set, their `policy_definitions`, `policy_set_definitions` and `role_definitions` are empty.",
 				Computed: true,
 				Type: types.MapType{
+					ElemType: archetypeType(),
+				},
+			},
+			"policy_definitions": {
+				MarkdownDescription: "The policy definitions of the archetypes, if `deduplicate_definitions` is set, otherwise it is empty",
+				Computed:            true,
+				Type:                policyDefinitionType(),
+			},
+			"policy_set_definitions": {
+				MarkdownDescription: "The policy set definitions of the archetypes, if `deduplicate_definitions` is set, otherwise it is empty",
+				Computed:            true,
+				Type:                policySetDefinitionType(),
+			},
+			"role_definitions": {
+				MarkdownDescription: "The role definitions of the archetypes, if `deduplicate_definitions` is set, otherwise it is empty",
+				Computed:            true,
+				Type:                roleDefinitionType(),
+			},
+		},
+	}, nil
+}
+
+func archetypeType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"name":                   types.StringType,
+			"layer":                  types.StringType,
+			"content_hash":           types.StringType,
+			"policy_definitions":     policyDefinitionType(),
+			"policy_set_definitions": policySetDefinitionType(),
+			"policy_assignments":     policyAssignmentType(),
+			"role_definitions":       roleDefinitionType(),
+			"role_assignments":       roleAssignmentType(),
+			// the names are lists, rather than sets, so that they are sorted
+			"policy_definition_names":     types.ListType{ElemType: types.StringType},
+			"policy_set_definition_names": types.ListType{ElemType: types.StringType},
+			"role_definition_names":       types.ListType{ElemType: types.StringType},
+		},
+	}
+}
+
+func policyDefinitionType() types.MapType {
+	return types.MapType{
+		ElemType: types.ObjectType{
+			AttrTypes: map[string]attr.Type{
+				"name":          types.StringType,
+				"resource_id":   types.StringType,
+				"resource_type": types.StringType,
+				"layer":         types.StringType,
+				"content_hash":  types.StringType,
+				"display_name":  types.StringType,
+				"policy_type":   types.StringType,
+				"mode":          types.StringType,
+				"description":   types.StringType,
+				"version":       types.StringType,
+				"category":      types.StringType,
+				"policy_rule":   types.StringType,
+				"metadata":      types.StringType,
+				"parameters":    types.StringType,
+			},
+		},
+	}
+}
+
+func policySetDefinitionType() types.MapType {
+	return types.MapType{
+		ElemType: types.ObjectType{
+			AttrTypes: map[string]attr.Type{
+				"name":                     types.StringType,
+				"layer":                    types.StringType,
+				"content_hash":             types.StringType,
+				"display_name":             types.StringType,
+				"description":              types.StringType,
+				"policy_type":              types.StringType,
+				"metadata":                 types.StringType,
+				"parameters":               types.StringType,
+				"policy_definition_groups": types.StringType,
+				"policy_definitions": types.ListType{
 					ElemType: types.ObjectType{
 						AttrTypes: map[string]attr.Type{
-							"name":               types.StringType,
-							"policy_definitions": policyDefinitionType(),
+							"policy_definition_reference_id": types.StringType,
+							"policy_definition_id":           types.StringType,
+							"parameters":                     types.StringType,
+							"group_names":                    types.ListType{ElemType: types.StringType},
+							"resolution_status":              types.StringType,
 						},
 					},
 				},
 			},
 		},
-	}, nil
+	}
 }
 
-func policyDefinitionType() types.MapType {
+func policyAssignmentType() types.MapType {
 	return types.MapType{
 		ElemType: types.ObjectType{
 			AttrTypes: map[string]attr.Type{
-				"name":         types.StringType,
-				"display_name": types.StringType,
-				"policy_type":  types.StringType,
-				"mode":         types.StringType,
-				"description":  types.StringType,
-				"policy_rule":  types.StringType,
-				"metadata":     types.StringType,
-				"parameters":   types.StringType,
+				"name":                 types.StringType,
+				"layer":                types.StringType,
+				"content_hash":         types.StringType,
+				"display_name":         types.StringType,
+				"description":          types.StringType,
+				"policy_definition_id": types.StringType,
+				"scope":                types.StringType,
+				"not_scopes":           types.ListType{ElemType: types.StringType},
+				"enforcement_mode":     types.StringType,
+				"identity_type":        types.StringType,
+				"location":             types.StringType,
+				"parameters":           types.StringType,
+			},
+		},
+	}
+}
+
+func roleAssignmentType() types.MapType {
+	return types.MapType{
+		ElemType: types.ObjectType{
+			AttrTypes: map[string]attr.Type{
+				"name":                 types.StringType,
+				"role_definition_name": types.StringType,
+				"role_definition_id":   types.StringType,
+				"principal_id":         types.StringType,
+				"scope":                types.StringType,
 			},
 		},
 	}
@@ -75,8 +215,26 @@ type archetypesDataSource struct {
 }
 
 type archetypesDataSourceData struct {
-	Id         int64                    `tfsdk:"id"`
-	Archetypes map[string]archetypeData `tfsdk:"archetypes"`
+	Id                     types.String                       `tfsdk:"id"`
+	TemplateVariables      types.Map                          `tfsdk:"template_variables"`
+	Names                  types.List                         `tfsdk:"names"`
+	IncludePolicyRules     types.Bool                         `tfsdk:"include_policy_rules"`
+	IncludeMetadata        types.Bool                         `tfsdk:"include_metadata"`
+	IncludeParameters      types.Bool                         `tfsdk:"include_parameters"`
+	DeduplicateDefinitions types.Bool                         `tfsdk:"deduplicate_definitions"`
+	Archetypes             map[string]archetypeData           `tfsdk:"archetypes"`
+	PolicyDefinitions      map[string]policyDefinitionsData   `tfsdk:"policy_definitions"`
+	PolicySetDefinitions   map[string]policySetDefinitionData `tfsdk:"policy_set_definitions"`
+	RoleDefinitions        map[string]roleDefinitionData      `tfsdk:"role_definitions"`
+}
+
+// archetypesDataSourceOptions are the arguments of the archetypes data source that select what is returned
+type archetypesDataSourceOptions struct {
+	names       []string
+	policyRules bool
+	metadata    bool
+	parameters  bool
+	deduplicate bool
 }
 
 func (d archetypesDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
@@ -90,56 +248,442 @@ func (d archetypesDataSource) Read(ctx context.Context, req tfsdk.ReadDataSource
 	// }
 
 	// Create the data structure that will be stored in the state
-	// We need an Id field to run the acceptance tests.
-	// Since there can only be one of these data sources per provider instance,
-	// we can fix this as a constant.
-	data := archetypesDataSourceData{
-		Id: 0,
+	data := archetypesDataSourceData{}
+
+	for name, target := range map[string]interface{}{
+		"template_variables":      &data.TemplateVariables,
+		"names":                   &data.Names,
+		"include_policy_rules":    &data.IncludePolicyRules,
+		"include_metadata":        &data.IncludeMetadata,
+		"include_parameters":      &data.IncludeParameters,
+		"deduplicate_definitions": &data.DeduplicateDefinitions,
+	} {
+		diags := req.Config.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName(name), target)
+		resp.Diagnostics.Append(diags...)
 	}
+	dsVars, diags := templateVariablesFromMap(ctx, data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+	opts, diags := archetypesDataSourceOptionsFromData(ctx, data, d.provider.client)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	vars := mergeTemplateVariables(d.provider.templateVariables, dsVars)
 
 	archs := make(map[string]archetypeData)
 
-	for ak := range d.provider.client.Archetypes {
-		archs[ak] = archetypeData{
-			Name:              types.String{Value: ak},
-			PolicyDefinitions: map[string]policyDefinitionsData{},
+	for _, ak := range opts.names {
+		ad := newArchetypeData(d.provider.client, ak, d.provider.client.Archetypes[ak], vars, tftypes.NewAttributePath().WithAttributeName("archetypes").WithElementKeyString(ak), &resp.Diagnostics)
+		slimArchetypeData(&ad, opts)
+		archs[ak] = ad
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	hashes := make(map[string]string, len(archs))
+	for ak, av := range archs {
+		hashes[ak] = av.ContentHash.Value
+	}
+	id, err := contentHash(hashes)
+	if err != nil {
+		resp.Diagnostics.AddError("Error generating archetypes", err.Error())
+		return
+	}
+
+	data.Id = id
+	data.Archetypes = archs
+	data.PolicyDefinitions = map[string]policyDefinitionsData{}
+	data.PolicySetDefinitions = map[string]policySetDefinitionData{}
+	data.RoleDefinitions = map[string]roleDefinitionData{}
+	if opts.deduplicate {
+		resp.Diagnostics.Append(deduplicateDefinitions(&data, opts.names)...)
+		if resp.Diagnostics.HasError() {
+			return
 		}
+	}
+	diags = resp.State.Set(ctx, &data)
 
-		for pdk, pdv := range d.provider.client.Archetypes[ak].PolicyDefinitions {
-			pdd := policyDefinitionsData{
-				Name:        types.String{Value: pdk},
-				DisplayName: types.String{Value: *pdv.Properties.DisplayName},
-				PolicyType:  types.String{Value: *pdv.Type},
-				Mode:        types.String{Value: *pdv.Properties.Mode},
-				Description: types.String{Value: *pdv.Properties.Description},
+	resp.Diagnostics.Append(diags...)
+}
+
+// archetypesDataSourceOptionsFromData returns the options of the archetypes data source from its configuration.
+// The names are sorted, and are all of the archetypes in the library if none are configured.
+func archetypesDataSourceOptionsFromData(ctx context.Context, data archetypesDataSourceData, az *alzlib.AlzLib) (archetypesDataSourceOptions, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	opts := archetypesDataSourceOptions{
+		policyRules: boolValueOrDefault(data.IncludePolicyRules, true),
+		metadata:    boolValueOrDefault(data.IncludeMetadata, true),
+		parameters:  boolValueOrDefault(data.IncludeParameters, true),
+		deduplicate: boolValueOrDefault(data.DeduplicateDefinitions, false),
+	}
+
+	if data.Names.Null || data.Names.Unknown {
+		for name := range az.Archetypes {
+			opts.names = append(opts.names, name)
+		}
+		sort.Strings(opts.names)
+		return opts, diags
+	}
+
+	diags.Append(data.Names.ElementsAs(ctx, &opts.names, false)...)
+	for i, name := range opts.names {
+		if _, ok := az.Archetypes[name]; !ok {
+			diags.AddAttributeError(tftypes.NewAttributePath().WithAttributeName("names").WithElementKeyInt(i), "Unknown archetype",
+				fmt.Sprintf("The archetype %s is not in the library", name))
+		}
+	}
+	sort.Strings(opts.names)
+	return opts, diags
+}
+
+// slimArchetypeData sets the heavy JSON fields of the archetype definitions that are not included to null
+func slimArchetypeData(ad *archetypeData, opts archetypesDataSourceOptions) {
+	for k, pdd := range ad.PolicyDefinitions {
+		if !opts.policyRules {
+			pdd.PolicyRule = types.String{Null: true}
+		}
+		if !opts.metadata {
+			pdd.Metadata = types.String{Null: true}
+		}
+		if !opts.parameters {
+			pdd.Parameters = types.String{Null: true}
+		}
+		ad.PolicyDefinitions[k] = pdd
+	}
+	for k, psdd := range ad.PolicySetDefinitions {
+		if !opts.metadata {
+			psdd.Metadata = types.String{Null: true}
+		}
+		if !opts.parameters {
+			psdd.Parameters = types.String{Null: true}
+		}
+		ad.PolicySetDefinitions[k] = psdd
+	}
+}
+
+// deduplicateDefinitions moves the definitions of the archetypes, which are processed in the order of the supplied names,
+// to the top level of the data source.
+// A definition is the same in every archetype that contains it, an error is returned if it is not.
+func deduplicateDefinitions(data *archetypesDataSourceData, names []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, ak := range names {
+		ad := data.Archetypes[ak]
+		path := tftypes.NewAttributePath().WithAttributeName("archetypes").WithElementKeyString(ak)
+		for k, v := range ad.PolicyDefinitions {
+			if existing, ok := data.PolicyDefinitions[k]; ok && !existing.ContentHash.Equal(v.ContentHash) {
+				diags.AddAttributeError(path.WithAttributeName("policy_definitions").WithElementKeyString(k), "Unable to deduplicate definitions",
+					fmt.Sprintf("The policy definition %s in archetype %s is not the same as in the other archetypes", k, ak))
 			}
+			data.PolicyDefinitions[k] = v
+		}
+		for k, v := range ad.PolicySetDefinitions {
+			if existing, ok := data.PolicySetDefinitions[k]; ok && !existing.ContentHash.Equal(v.ContentHash) {
+				diags.AddAttributeError(path.WithAttributeName("policy_set_definitions").WithElementKeyString(k), "Unable to deduplicate definitions",
+					fmt.Sprintf("The policy set definition %s in archetype %s is not the same as in the other archetypes", k, ak))
+			}
+			data.PolicySetDefinitions[k] = v
+		}
+		for k, v := range ad.RoleDefinitions {
+			if existing, ok := data.RoleDefinitions[k]; ok && !existing.ContentHash.Equal(v.ContentHash) {
+				diags.AddAttributeError(path.WithAttributeName("role_definitions").WithElementKeyString(k), "Unable to deduplicate definitions",
+					fmt.Sprintf("The role definition %s in archetype %s is not the same as in the other archetypes", k, ak))
+			}
+			data.RoleDefinitions[k] = v
+		}
+		ad.PolicyDefinitions = map[string]policyDefinitionsData{}
+		ad.PolicySetDefinitions = map[string]policySetDefinitionData{}
+		ad.RoleDefinitions = map[string]roleDefinitionData{}
+		data.Archetypes[ak] = ad
+	}
+	return diags
+}
+
+// newArchetypeData converts the supplied archetype into the data source model.
+// If vars is not nil, the placeholders in the lib files are rendered.
+// Any problems are added to the supplied diagnostics, the path is that of the archetype attributes.
+func newArchetypeData(az *alzlib.AlzLib, name string, archetype *alzlib.ArchetypeDefinition, vars alzlib.TemplateVariables, path *tftypes.AttributePath, diags *diag.Diagnostics) archetypeData {
+	ad := archetypeData{
+		Name:                 types.String{Value: name},
+		Layer:                layerValue(az, alzlib.KindArchetypeDefinition, name),
+		PolicyDefinitions:    map[string]policyDefinitionsData{},
+		PolicySetDefinitions: map[string]policySetDefinitionData{},
+		PolicyAssignments:    map[string]policyAssignmentData{},
+		RoleDefinitions:      map[string]roleDefinitionData{},
+		RoleAssignments:      map[string]roleAssignmentData{},
+	}
 
-			policyRule := pdv.Properties.PolicyRule.(map[string]interface{})
-			policyRuleStr := flattenJSON(policyRule)
-			if policyRuleStr == "" {
-				resp.Diagnostics.AddError(fmt.Sprintf("Error generating archetype %s", ak), fmt.Sprintf("Unable to read policy rule in policy definition %s", pdk))
+	for pdk, pdv := range archetype.PolicyDefinitions {
+		if vars != nil {
+			rendered, err := az.RenderPolicyDefinition(pdv, vars)
+			if err != nil {
+				addRenderErrorDiagnostic(diags, err)
+				continue
 			}
-			pdd.PolicyRule = types.String{Value: policyRuleStr}
+			pdv = *rendered
+		}
 
-			pdd.Metadata = types.String{Value: flattenJSON(pdv.Properties.Metadata)}
+		pdd, pdDiags := newPolicyDefinitionData(pdk, pdv, path.WithAttributeName("policy_definitions").WithElementKeyString(pdk))
+		diags.Append(pdDiags...)
+		pdd.Layer = layerValue(az, alzlib.KindPolicyDefinition, pdk)
+		ad.PolicyDefinitions[pdk] = pdd
+	}
 
-			parametersStr, err := flattenParameterDefinitionsValueToString(pdv.Properties.Parameters)
+	for psk, psv := range archetype.PolicySetDefinitions {
+		if vars != nil {
+			rendered, err := az.RenderPolicySetDefinition(psv, vars)
 			if err != nil {
-				resp.Diagnostics.AddError(fmt.Sprintf("Error generating archetype %s", ak), fmt.Sprintf("Unable to read policy parameters in policy definition %s", pdk))
+				addRenderErrorDiagnostic(diags, err)
+				continue
 			}
-			pdd.Parameters = types.String{Value: parametersStr}
+			psv = *rendered
+		}
 
-			archs[ak].PolicyDefinitions[pdk] = pdd
+		psd, psdDiags := newPolicySetDefinitionData(psk, psv, path.WithAttributeName("policy_set_definitions").WithElementKeyString(psk))
+		diags.Append(psdDiags...)
+		psd.Layer = layerValue(az, alzlib.KindPolicySetDefinition, psk)
+		setResolutionStatus(az, psk, &psd)
+		ad.PolicySetDefinitions[psk] = psd
+	}
+
+	for pak, pav := range archetype.PolicyAssignments {
+		if vars != nil {
+			rendered, err := az.RenderPolicyAssignment(pav, vars)
+			if err != nil {
+				addRenderErrorDiagnostic(diags, err)
+				continue
+			}
+			pav = *rendered
+		}
+
+		pad, err := newPolicyAssignmentData(pak, pav)
+		if err != nil {
+			diags.AddError(fmt.Sprintf("Error generating archetype %s", name), fmt.Sprintf("Unable to read policy assignment %s: %s", pak, err))
+			continue
 		}
+		pad.Layer = layerValue(az, alzlib.KindPolicyAssignment, pak)
+		ad.PolicyAssignments[pak] = pad
 	}
 
-	data.Archetypes = archs
-	diags := resp.State.Set(ctx, &data)
+	for rdk, rdv := range archetype.RoleDefinitions {
+		if vars != nil {
+			rendered, err := az.RenderRoleDefinition(rdv, vars)
+			if err != nil {
+				addRenderErrorDiagnostic(diags, err)
+				continue
+			}
+			rdv = *rendered
+		}
+		rdd, err := newRoleDefinitionData(rdv)
+		if err != nil {
+			diags.AddError(fmt.Sprintf("Error generating archetype %s", name), fmt.Sprintf("Unable to read role definition %s: %s", rdk, err))
+			continue
+		}
+		rdd.Layer = layerValue(az, alzlib.KindRoleDefinition, rdk)
+		ad.RoleDefinitions[rdk] = rdd
+	}
 
-	resp.Diagnostics.Append(diags...)
+	// role assignments are keyed by name, which changes when they are rendered
+	roleAssignments := make(map[string]alzlib.RoleAssignment, len(archetype.RoleAssignments))
+	for _, rav := range archetype.RoleAssignments {
+		if vars != nil {
+			rendered, err := az.RenderRoleAssignment(name, rav, vars)
+			if err != nil {
+				addRenderErrorDiagnostic(diags, err)
+				continue
+			}
+			rav = *rendered
+		}
+		roleAssignments[rav.Name] = rav
+		ad.RoleAssignments[rav.Name] = newRoleAssignmentData(rav)
+	}
+
+	ad.PolicyDefinitionNames = sortedNamesValue(ad.PolicyDefinitions)
+	ad.PolicySetDefinitionNames = sortedNamesValue(ad.PolicySetDefinitions)
+	ad.RoleDefinitionNames = sortedNamesValue(ad.RoleDefinitions)
+
+	// the archetype content hash covers the content hashes of its objects
+	hashes := map[string]interface{}{
+		"policy_definitions":     contentHashes(ad.PolicyDefinitions, func(v policyDefinitionsData) types.String { return v.ContentHash }),
+		"policy_set_definitions": contentHashes(ad.PolicySetDefinitions, func(v policySetDefinitionData) types.String { return v.ContentHash }),
+		"policy_assignments":     contentHashes(ad.PolicyAssignments, func(v policyAssignmentData) types.String { return v.ContentHash }),
+		"role_definitions":       contentHashes(ad.RoleDefinitions, func(v roleDefinitionData) types.String { return v.ContentHash }),
+		"role_assignments":       roleAssignments,
+	}
+	hash, err := contentHash(hashes)
+	if err != nil {
+		diags.AddError(fmt.Sprintf("Error generating archetype %s", name), err.Error())
+	}
+	ad.ContentHash = hash
+
+	return ad
+}
+
+// newPolicyDefinitionData converts the supplied policy definition into the data source model.
+// Fields that are not in the policy definition are null.
+// Values that do not have the expected shape are returned as diagnostics for the attribute at the supplied path.
+func newPolicyDefinitionData(name string, pd armpolicy.Definition, path *tftypes.AttributePath) (policyDefinitionsData, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	pdd := policyDefinitionsData{
+		Name:         types.String{Value: name},
+		ResourceId:   stringValue(pd.ID),
+		ResourceType: stringValue(pd.Type),
+		PolicyType:   types.String{Null: true},
+		Version:      types.String{Null: true},
+		Category:     types.String{Null: true},
+	}
+
+	props := pd.Properties
+	if props == nil {
+		props = &armpolicy.DefinitionProperties{}
+	}
+	pdd.DisplayName = stringValue(props.DisplayName)
+	pdd.Mode = stringValue(props.Mode)
+	pdd.Description = stringValue(props.Description)
+	if props.PolicyType != nil {
+		pdd.PolicyType = types.String{Value: string(*props.PolicyType)}
+	}
+
+	if _, ok := props.PolicyRule.(map[string]interface{}); props.PolicyRule != nil && !ok {
+		diags.AddAttributeError(path.WithAttributeName("policy_rule"), "Invalid policy definition",
+			fmt.Sprintf("The policy rule of policy definition %s must be a JSON object, got %T", name, props.PolicyRule))
+		pdd.PolicyRule = types.String{Null: true}
+	} else {
+		pdd.PolicyRule = jsonStringValue(props.PolicyRule, path.WithAttributeName("policy_rule"), &diags)
+	}
+
+	// the version and category are conventions of the policy definition metadata
+	metadata, ok := props.Metadata.(map[string]interface{})
+	if props.Metadata != nil && !ok {
+		diags.AddAttributeError(path.WithAttributeName("metadata"), "Invalid policy definition",
+			fmt.Sprintf("The metadata of policy definition %s must be a JSON object, got %T", name, props.Metadata))
+	}
+	pdd.Metadata = jsonStringValue(metadata, path.WithAttributeName("metadata"), &diags)
+	pdd.Version = metadataStringValue(metadata, "version", path.WithAttributeName("version"), &diags)
+	pdd.Category = metadataStringValue(metadata, "category", path.WithAttributeName("category"), &diags)
+
+	pdd.Parameters = jsonStringValue(props.Parameters, path.WithAttributeName("parameters"), &diags)
+
+	var err error
+	if pdd.ContentHash, err = contentHash(pd); err != nil {
+		diags.AddAttributeError(path.WithAttributeName("content_hash"), "Invalid policy definition",
+			fmt.Sprintf("Unable to hash policy definition %s: %s", name, err))
+	}
+
+	return pdd, diags
 }
 
-func flattenParameterDefinitionsValueToString(input map[string]*armpolicy.ParameterDefinitionsValue) (string, error) {
+// newRoleAssignmentData converts the supplied role assignment into the data source model
+func newRoleAssignmentData(ra alzlib.RoleAssignment) roleAssignmentData {
+	return roleAssignmentData{
+		Name:               types.String{Value: ra.Name},
+		RoleDefinitionName: types.String{Value: ra.RoleDefinitionName},
+		RoleDefinitionId:   types.String{Value: ra.RoleDefinitionId},
+		PrincipalId:        types.String{Value: ra.PrincipalId},
+		Scope:              types.String{Value: ra.Scope},
+	}
+}
+
+// newPolicySetDefinitionData converts the supplied policy set definition into the data source model.
+// The member policy definitions are returned in the order they are declared in the lib file.
+// Fields that are not in the policy set definition are null.
+// Values that do not have the expected shape are returned as diagnostics for the attribute at the supplied path.
+func newPolicySetDefinitionData(name string, psd armpolicy.SetDefinition, path *tftypes.AttributePath) (policySetDefinitionData, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	props := psd.Properties
+	if props == nil {
+		props = &armpolicy.SetDefinitionProperties{}
+	}
+
+	psdd := policySetDefinitionData{
+		Name:              types.String{Value: name},
+		DisplayName:       stringValue(props.DisplayName),
+		Description:       stringValue(props.Description),
+		PolicyType:        types.String{Null: true},
+		PolicyDefinitions: make([]policySetDefinitionMemberData, 0, len(props.PolicyDefinitions)),
+	}
+	if props.PolicyType != nil {
+		psdd.PolicyType = types.String{Value: string(*props.PolicyType)}
+	}
+
+	if _, ok := props.Metadata.(map[string]interface{}); props.Metadata != nil && !ok {
+		diags.AddAttributeError(path.WithAttributeName("metadata"), "Invalid policy set definition",
+			fmt.Sprintf("The metadata of policy set definition %s must be a JSON object, got %T", name, props.Metadata))
+		psdd.Metadata = types.String{Null: true}
+	} else {
+		psdd.Metadata = jsonStringValue(props.Metadata, path.WithAttributeName("metadata"), &diags)
+	}
+	psdd.Parameters = jsonStringValue(props.Parameters, path.WithAttributeName("parameters"), &diags)
+	psdd.PolicyDefinitionGroups = jsonStringValue(props.PolicyDefinitionGroups, path.WithAttributeName("policy_definition_groups"), &diags)
+
+	for i, ref := range props.PolicyDefinitions {
+		memberPath := path.WithAttributeName("policy_definitions").WithElementKeyInt(i)
+		if ref == nil {
+			diags.AddAttributeError(memberPath, "Invalid policy set definition",
+				fmt.Sprintf("The policy definition reference at index %d of policy set definition %s is empty", i, name))
+			ref = &armpolicy.DefinitionReference{}
+		}
+		psdd.PolicyDefinitions = append(psdd.PolicyDefinitions, policySetDefinitionMemberData{
+			PolicyDefinitionReferenceId: stringValue(ref.PolicyDefinitionReferenceID),
+			PolicyDefinitionId:          stringValue(ref.PolicyDefinitionID),
+			Parameters:                  jsonStringValue(ref.Parameters, memberPath.WithAttributeName("parameters"), &diags),
+			GroupNames:                  stringListValue(ref.GroupNames),
+			ResolutionStatus:            types.String{Null: true},
+		})
+	}
+
+	var err error
+	if psdd.ContentHash, err = contentHash(psd); err != nil {
+		diags.AddAttributeError(path.WithAttributeName("content_hash"), "Invalid policy set definition",
+			fmt.Sprintf("Unable to hash policy set definition %s: %s", name, err))
+	}
+
+	return psdd, diags
+}
+
+// newPolicyAssignmentData converts the supplied policy assignment into the data source model.
+// The parameters are the merged values from the assignment file and the archetype_config.
+func newPolicyAssignmentData(name string, pa armpolicy.Assignment) (policyAssignmentData, error) {
+	pad := policyAssignmentData{
+		Name:     types.String{Value: name},
+		Location: stringValue(pa.Location),
+	}
+
+	pad.IdentityType = types.String{Null: true}
+	if pa.Identity != nil && pa.Identity.Type != nil {
+		pad.IdentityType = types.String{Value: string(*pa.Identity.Type)}
+	}
+
+	props := pa.Properties
+	if props == nil {
+		props = &armpolicy.AssignmentProperties{}
+	}
+	pad.DisplayName = stringValue(props.DisplayName)
+	pad.Description = stringValue(props.Description)
+	pad.PolicyDefinitionId = stringValue(props.PolicyDefinitionID)
+	pad.Scope = stringValue(props.Scope)
+	pad.NotScopes = stringListValue(props.NotScopes)
+
+	pad.EnforcementMode = types.String{Null: true}
+	if props.EnforcementMode != nil {
+		pad.EnforcementMode = types.String{Value: string(*props.EnforcementMode)}
+	}
+
+	parametersStr, err := flattenParameterValuesValueToString(props.Parameters)
+	if err != nil {
+		return pad, err
+	}
+	pad.Parameters = types.String{Value: parametersStr}
+
+	if pad.ContentHash, err = contentHash(pa); err != nil {
+		return pad, err
+	}
+
+	return pad, nil
+}
+
+func flattenParameterValuesValueToString(input map[string]*armpolicy.ParameterValuesValue) (string, error) {
 	if len(input) == 0 {
 		return "", nil
 	}
@@ -149,10 +693,5 @@ func flattenParameterDefinitionsValueToString(input map[string]*armpolicy.Parame
 		return "", err
 	}
 
-	compactJson := bytes.Buffer{}
-	if err := json.Compact(&compactJson, result); err != nil {
-		return "", err
-	}
-
-	return compactJson.String(), nil
+	return string(result), nil
 }
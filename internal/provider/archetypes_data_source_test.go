@@ -1,11 +1,25 @@
 package provider
 
 import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armpolicy"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/library"
 )
 
+// sha256Regex matches the hex encoded SHA-256 fingerprints of the id and content_hash attributes
+var sha256Regex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
 func TestAccArchetypesDataSource(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
@@ -17,8 +31,50 @@ func TestAccArchetypesDataSource(t *testing.T) {
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.name", "es_root"),
 					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_definitions.%", "104"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_set_definitions.%", "7"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_set_definitions.Deploy-Sql-Security.policy_type", "Custom"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_definitions.Deny-Subnet-Without-Nsg.policy_type", "Custom"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_definitions.Deny-Subnet-Without-Nsg.category", "Network"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_set_definitions.Deploy-MDFC-Config.policy_definitions.#", "12"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.role_definitions.%", "5"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.role_definitions.Network-Management.role_type", "customRole"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.policy_assignments.%", "5"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.role_assignments.%", "0"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.policy_assignments.Deny-DataB-Pip.identity_type", "None"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.policy_assignments.Deny-DataB-Pip.parameters", `{"effect":{"value":"Deny"}}`),
+					resource.TestMatchResourceAttr("data.alzlib_archetypes.test", "id", sha256Regex),
+					resource.TestMatchResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.content_hash", sha256Regex),
+					resource.TestMatchResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.policy_assignments.Deny-DataB-Pip.content_hash", sha256Regex),
+				),
+			},
+			// Read testing with slimmed state
+			{
+				Config: testAccArchetypesDataSourceConfigSlim,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.%", "2"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_definitions.%", "0"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_definition_names.#", "104"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "policy_definitions.%", "104"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "policy_definitions.Deny-Subnet-Without-Nsg.category", "Network"),
+					resource.TestCheckNoResourceAttr("data.alzlib_archetypes.test", "policy_definitions.Deny-Subnet-Without-Nsg.policy_rule"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "role_definitions.%", "5"),
+				),
+			},
+			// Read testing with template variables
+			{
+				Config: testAccArchetypesDataSourceConfigTemplateVariables,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_root.policy_definitions.%", "104"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.policy_assignments.Deny-DataB-Pip.scope", "/providers/Microsoft.Management/managementGroups/alz"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.policy_assignments.Deny-DataB-Pip.location", "uksouth"),
+					resource.TestCheckResourceAttr("data.alzlib_archetypes.test", "archetypes.es_corp.policy_assignments.Deny-DataB-Pip.policy_definition_id", "/providers/Microsoft.Management/managementGroups/alz/providers/Microsoft.Authorization/policyDefinitions/Deny-Databricks-NoPublicIp"),
 				),
 			},
+			// Read testing with a missing template variable
+			{
+				Config:      testAccArchetypesDataSourceConfigMissingTemplateVariable,
+				ExpectError: regexp.MustCompile(`(?s)policy_assignment_es_deploy_private_dns_zones\.tmpl\.json.*private_dns_zone_prefix`),
+			},
 		},
 	})
 }
@@ -26,3 +82,270 @@ func TestAccArchetypesDataSource(t *testing.T) {
 const testAccArchetypesDataSourceConfig = `
 data "alzlib_archetypes" "test" {}
 `
+
+const testAccArchetypesDataSourceConfigSlim = `
+data "alzlib_archetypes" "test" {
+  names                   = ["es_root", "es_corp"]
+  include_policy_rules    = false
+  deduplicate_definitions = true
+}
+`
+
+const testAccArchetypesDataSourceConfigTemplateVariables = `
+data "alzlib_archetypes" "test" {
+  template_variables = {
+    root_scope_id           = "alz"
+    current_scope_id        = "alz"
+    default_location        = "uksouth"
+    private_dns_zone_prefix = ""
+  }
+}
+`
+
+const testAccArchetypesDataSourceConfigMissingTemplateVariable = `
+data "alzlib_archetypes" "test" {
+  template_variables = {
+    root_scope_id    = "alz"
+    current_scope_id = "alz"
+    default_location = "uksouth"
+  }
+}
+`
+
+// TestNewArchetypeDataTemplateVariables tests that the placeholders are rendered,
+// and that a missing template variable is a diagnostic that names the file and the variable
+func TestNewArchetypeDataTemplateVariables(t *testing.T) {
+	az, err := alzlib.NewAlzLibFromLayers(library.Layer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars := alzlib.TemplateVariables{
+		alzlib.TemplateVariableRootScopeId:          "alz",
+		alzlib.TemplateVariableCurrentScopeId:       "alz",
+		alzlib.TemplateVariableDefaultLocation:      "uksouth",
+		alzlib.TemplateVariablePrivateDnsZonePrefix: "",
+	}
+	path := tftypes.NewAttributePath().WithAttributeName("archetypes").WithElementKeyString("es_corp")
+
+	var diags diag.Diagnostics
+	ad := newArchetypeData(az, "es_corp", az.Archetypes["es_corp"], vars, path, &diags)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	pa := ad.PolicyAssignments["Deny-DataB-Pip"]
+	if want := "/providers/Microsoft.Management/managementGroups/alz/providers/Microsoft.Authorization/policyDefinitions/Deny-Databricks-NoPublicIp"; pa.PolicyDefinitionId.Value != want {
+		t.Errorf("expected policy definition id %s, got %s", want, pa.PolicyDefinitionId.Value)
+	}
+	if pa.Location.Value != "uksouth" {
+		t.Errorf("expected location uksouth, got %s", pa.Location.Value)
+	}
+
+	delete(vars, alzlib.TemplateVariablePrivateDnsZonePrefix)
+	diags = nil
+	newArchetypeData(az, "es_corp", az.Archetypes["es_corp"], vars, path, &diags)
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diags)
+	}
+	want := "The policy_assignment Deploy-Private-DNS-Zones, in file " + filepath.Join(library.LayerName, "policy_assignments", "policy_assignment_es_deploy_private_dns_zones.tmpl.json") + ", " +
+		"references template variable(s) that have not been supplied: [private_dns_zone_prefix]."
+	if d := diags[0].Detail(); !strings.HasPrefix(d, want) {
+		t.Errorf("expected diagnostic %q, got %q", want, d)
+	}
+}
+
+// TestNewPolicyDefinitionData tests that missing fields are null and that unexpected values are attribute diagnostics
+func TestNewPolicyDefinitionData(t *testing.T) {
+	path := tftypes.NewAttributePath().WithAttributeName("policy_definitions").WithElementKeyString("test")
+
+	pdd, diags := newPolicyDefinitionData("test", armpolicy.Definition{}, path)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if !pdd.DisplayName.Null || !pdd.Description.Null || !pdd.Mode.Null || !pdd.PolicyType.Null || !pdd.PolicyRule.Null || !pdd.Metadata.Null || !pdd.Category.Null {
+		t.Errorf("expected the fields of a policy definition without properties to be null, got %+v", pdd)
+	}
+
+	custom := armpolicy.PolicyTypeCustom
+	id := "/providers/Microsoft.Management/managementGroups/root/providers/Microsoft.Authorization/policyDefinitions/test"
+	resourceType := "Microsoft.Authorization/policyDefinitions"
+	pdd, diags = newPolicyDefinitionData("test", armpolicy.Definition{
+		ID:   &id,
+		Type: &resourceType,
+		Properties: &armpolicy.DefinitionProperties{
+			PolicyType: &custom,
+			PolicyRule: map[string]interface{}{"then": map[string]interface{}{"effect": "audit"}},
+			Metadata:   map[string]interface{}{"version": "1.0.0", "category": "Network"},
+		},
+	}, path)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if pdd.ResourceId.Value != id || pdd.PolicyType.Value != "Custom" || pdd.ResourceType.Value != resourceType || pdd.Version.Value != "1.0.0" || pdd.Category.Value != "Network" {
+		t.Errorf("unexpected policy definition data %+v", pdd)
+	}
+	if pdd.PolicyRule.Value != `{"then":{"effect":"audit"}}` {
+		t.Errorf("unexpected policy rule %s", pdd.PolicyRule.Value)
+	}
+
+	_, diags = newPolicyDefinitionData("test", armpolicy.Definition{
+		Properties: &armpolicy.DefinitionProperties{
+			PolicyRule: "not an object",
+			Metadata:   map[string]interface{}{"category": 1},
+		},
+	}, path)
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", diags)
+	}
+	for i, attr := range []string{"policy_rule", "category"} {
+		d, ok := diags[i].(interface{ Path() *tftypes.AttributePath })
+		if !ok || !d.Path().Equal(path.WithAttributeName(attr)) {
+			t.Errorf("expected diagnostic %d to be for attribute %s, got %v", i, attr, diags[i])
+		}
+	}
+}
+
+// TestNewPolicySetDefinitionData tests that missing fields are null and that unexpected values are attribute diagnostics
+func TestNewPolicySetDefinitionData(t *testing.T) {
+	path := tftypes.NewAttributePath().WithAttributeName("policy_set_definitions").WithElementKeyString("test")
+
+	psdd, diags := newPolicySetDefinitionData("test", armpolicy.SetDefinition{
+		Properties: &armpolicy.SetDefinitionProperties{
+			PolicyDefinitions: []*armpolicy.DefinitionReference{{}},
+		},
+	}, path)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if !psdd.DisplayName.Null || !psdd.PolicyType.Null || !psdd.Metadata.Null || !psdd.Parameters.Null || !psdd.PolicyDefinitionGroups.Null {
+		t.Errorf("expected the missing fields of the policy set definition to be null, got %+v", psdd)
+	}
+	if len(psdd.PolicyDefinitions) != 1 || !psdd.PolicyDefinitions[0].Parameters.Null || !psdd.PolicyDefinitions[0].PolicyDefinitionId.Null {
+		t.Errorf("expected the missing fields of the member to be null, got %+v", psdd.PolicyDefinitions)
+	}
+
+	value := "value"
+	psdd, diags = newPolicySetDefinitionData("test", armpolicy.SetDefinition{
+		Properties: &armpolicy.SetDefinitionProperties{
+			Metadata: map[string]interface{}{"category": "Network"},
+			PolicyDefinitions: []*armpolicy.DefinitionReference{{
+				Parameters: map[string]*armpolicy.ParameterValuesValue{"effect": {Value: &value}},
+			}},
+		},
+	}, path)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if psdd.Metadata.Value != `{"category":"Network"}` || psdd.PolicyDefinitions[0].Parameters.Value != `{"effect":{"value":"value"}}` {
+		t.Errorf("unexpected policy set definition data %+v", psdd)
+	}
+
+	_, diags = newPolicySetDefinitionData("test", armpolicy.SetDefinition{
+		Properties: &armpolicy.SetDefinitionProperties{
+			Metadata:          "not an object",
+			PolicyDefinitions: []*armpolicy.DefinitionReference{{}, nil},
+		},
+	}, path)
+	want := []*tftypes.AttributePath{
+		path.WithAttributeName("metadata"),
+		path.WithAttributeName("policy_definitions").WithElementKeyInt(1),
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), diags)
+	}
+	for i, p := range want {
+		d, ok := diags[i].(interface{ Path() *tftypes.AttributePath })
+		if !ok || !d.Path().Equal(p) {
+			t.Errorf("expected diagnostic %d to be for %s, got %v", i, p, diags[i])
+		}
+	}
+}
+
+func TestArchetypesDataSourceOptionsFromData(t *testing.T) {
+	ctx := context.Background()
+	az, err := alzlib.NewAlzLibFromLayers(library.Layer())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, diags := archetypesDataSourceOptionsFromData(ctx, archetypesDataSourceData{
+		Names:                  types.List{Null: true, ElemType: types.StringType},
+		IncludePolicyRules:     types.Bool{Null: true},
+		IncludeMetadata:        types.Bool{Value: false},
+		IncludeParameters:      types.Bool{Null: true},
+		DeduplicateDefinitions: types.Bool{Null: true},
+	}, az)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if len(opts.names) != len(az.Archetypes) || !opts.policyRules || opts.metadata || !opts.parameters || opts.deduplicate {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	_, diags = archetypesDataSourceOptionsFromData(ctx, archetypesDataSourceData{
+		Names: types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "es_root"}, types.String{Value: "unknown"}}},
+	}, az)
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diags)
+	}
+	if d, ok := diags[0].(interface{ Path() *tftypes.AttributePath }); !ok || !d.Path().Equal(tftypes.NewAttributePath().WithAttributeName("names").WithElementKeyInt(1)) {
+		t.Errorf("expected a diagnostic for the unknown archetype, got %v", diags[0])
+	}
+}
+
+func TestSlimArchetypeData(t *testing.T) {
+	ad := archetypeData{
+		PolicyDefinitions: map[string]policyDefinitionsData{
+			"pd": {PolicyRule: types.String{Value: "{}"}, Metadata: types.String{Value: "{}"}, Parameters: types.String{Value: "{}"}},
+		},
+		PolicySetDefinitions: map[string]policySetDefinitionData{
+			"psd": {Metadata: types.String{Value: "{}"}, Parameters: types.String{Value: "{}"}},
+		},
+	}
+	slimArchetypeData(&ad, archetypesDataSourceOptions{metadata: true})
+	pdd, psdd := ad.PolicyDefinitions["pd"], ad.PolicySetDefinitions["psd"]
+	if !pdd.PolicyRule.Null || pdd.Metadata.Null || !pdd.Parameters.Null {
+		t.Errorf("unexpected policy definition %+v", pdd)
+	}
+	if psdd.Metadata.Null || !psdd.Parameters.Null {
+		t.Errorf("unexpected policy set definition %+v", psdd)
+	}
+}
+
+func TestDeduplicateDefinitions(t *testing.T) {
+	newData := func(hashB string) *archetypesDataSourceData {
+		return &archetypesDataSourceData{
+			Archetypes: map[string]archetypeData{
+				"a": {PolicyDefinitions: map[string]policyDefinitionsData{"pd": {ContentHash: types.String{Value: "1"}}}},
+				"b": {
+					PolicyDefinitions: map[string]policyDefinitionsData{"pd": {ContentHash: types.String{Value: hashB}}},
+					RoleDefinitions:   map[string]roleDefinitionData{"rd": {ContentHash: types.String{Value: "2"}}},
+				},
+			},
+			PolicyDefinitions:    map[string]policyDefinitionsData{},
+			PolicySetDefinitions: map[string]policySetDefinitionData{},
+			RoleDefinitions:      map[string]roleDefinitionData{},
+		}
+	}
+
+	data := newData("1")
+	if diags := deduplicateDefinitions(data, []string{"a", "b"}); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if len(data.PolicyDefinitions) != 1 || len(data.RoleDefinitions) != 1 {
+		t.Errorf("expected the definitions to be at the top level, got %+v", data)
+	}
+	for name, ad := range data.Archetypes {
+		if len(ad.PolicyDefinitions) != 0 || len(ad.RoleDefinitions) != 0 {
+			t.Errorf("expected archetype %s to have no definitions, got %+v", name, ad)
+		}
+	}
+
+	data = newData("3")
+	diags := deduplicateDefinitions(data, []string{"a", "b"})
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diags)
+	}
+	if d, ok := diags[0].(interface{ Path() *tftypes.AttributePath }); !ok || !d.Path().Equal(tftypes.NewAttributePath().WithAttributeName("archetypes").WithElementKeyString("b").WithAttributeName("policy_definitions").WithElementKeyString("pd")) {
+		t.Errorf("expected a diagnostic for the policy definition in archetype b, got %v", diags[0])
+	}
+}
@@ -5,17 +5,113 @@ import (
 )
 
 type archetypeData struct {
-	Name              types.String                     `tfsdk:"name"`
-	PolicyDefinitions map[string]policyDefinitionsData `tfsdk:"policy_definitions"`
+	Name                 types.String                       `tfsdk:"name"`
+	Layer                types.String                       `tfsdk:"layer"`
+	ContentHash          types.String                       `tfsdk:"content_hash"`
+	PolicyDefinitions    map[string]policyDefinitionsData   `tfsdk:"policy_definitions"`
+	PolicySetDefinitions map[string]policySetDefinitionData `tfsdk:"policy_set_definitions"`
+	PolicyAssignments    map[string]policyAssignmentData    `tfsdk:"policy_assignments"`
+	RoleDefinitions      map[string]roleDefinitionData      `tfsdk:"role_definitions"`
+	RoleAssignments      map[string]roleAssignmentData      `tfsdk:"role_assignments"`
+	// the names of the definitions, which are returned even if the definitions are deduplicated
+	PolicyDefinitionNames    types.List `tfsdk:"policy_definition_names"`
+	PolicySetDefinitionNames types.List `tfsdk:"policy_set_definition_names"`
+	RoleDefinitionNames      types.List `tfsdk:"role_definition_names"`
 }
 
 type policyDefinitionsData struct {
-	Name        types.String `tfsdk:"name"`
-	DisplayName types.String `tfsdk:"display_name"`
-	PolicyType  types.String `tfsdk:"policy_type"`
-	Mode        types.String `tfsdk:"mode"`
-	Description types.String `tfsdk:"description"`
-	PolicyRule  types.String `tfsdk:"policy_rule"`
-	Metadata    types.String `tfsdk:"metadata"`
-	Parameters  types.String `tfsdk:"parameters"`
+	Name         types.String `tfsdk:"name"`
+	ResourceId   types.String `tfsdk:"resource_id"`
+	ResourceType types.String `tfsdk:"resource_type"`
+	Layer        types.String `tfsdk:"layer"`
+	ContentHash  types.String `tfsdk:"content_hash"`
+	DisplayName  types.String `tfsdk:"display_name"`
+	PolicyType   types.String `tfsdk:"policy_type"`
+	Mode         types.String `tfsdk:"mode"`
+	Description  types.String `tfsdk:"description"`
+	Version      types.String `tfsdk:"version"`
+	Category     types.String `tfsdk:"category"`
+	PolicyRule   types.String `tfsdk:"policy_rule"`
+	Metadata     types.String `tfsdk:"metadata"`
+	Parameters   types.String `tfsdk:"parameters"`
+}
+
+type policyAssignmentData struct {
+	Name               types.String `tfsdk:"name"`
+	Layer              types.String `tfsdk:"layer"`
+	ContentHash        types.String `tfsdk:"content_hash"`
+	DisplayName        types.String `tfsdk:"display_name"`
+	Description        types.String `tfsdk:"description"`
+	PolicyDefinitionId types.String `tfsdk:"policy_definition_id"`
+	Scope              types.String `tfsdk:"scope"`
+	NotScopes          types.List   `tfsdk:"not_scopes"`
+	EnforcementMode    types.String `tfsdk:"enforcement_mode"`
+	IdentityType       types.String `tfsdk:"identity_type"`
+	Location           types.String `tfsdk:"location"`
+	Parameters         types.String `tfsdk:"parameters"`
+}
+
+type policySetDefinitionData struct {
+	Name                   types.String                    `tfsdk:"name"`
+	Layer                  types.String                    `tfsdk:"layer"`
+	ContentHash            types.String                    `tfsdk:"content_hash"`
+	DisplayName            types.String                    `tfsdk:"display_name"`
+	Description            types.String                    `tfsdk:"description"`
+	PolicyType             types.String                    `tfsdk:"policy_type"`
+	Metadata               types.String                    `tfsdk:"metadata"`
+	Parameters             types.String                    `tfsdk:"parameters"`
+	PolicyDefinitionGroups types.String                    `tfsdk:"policy_definition_groups"`
+	PolicyDefinitions      []policySetDefinitionMemberData `tfsdk:"policy_definitions"`
+}
+
+type policySetDefinitionMemberData struct {
+	PolicyDefinitionReferenceId types.String `tfsdk:"policy_definition_reference_id"`
+	PolicyDefinitionId          types.String `tfsdk:"policy_definition_id"`
+	Parameters                  types.String `tfsdk:"parameters"`
+	GroupNames                  types.List   `tfsdk:"group_names"`
+	ResolutionStatus            types.String `tfsdk:"resolution_status"`
+}
+
+type roleDefinitionData struct {
+	Name             types.String                   `tfsdk:"name"`
+	Layer            types.String                   `tfsdk:"layer"`
+	ContentHash      types.String                   `tfsdk:"content_hash"`
+	RoleName         types.String                   `tfsdk:"role_name"`
+	Description      types.String                   `tfsdk:"description"`
+	RoleType         types.String                   `tfsdk:"role_type"`
+	Permissions      []roleDefinitionPermissionData `tfsdk:"permissions"`
+	AssignableScopes types.List                     `tfsdk:"assignable_scopes"`
+}
+
+type roleDefinitionPermissionData struct {
+	Actions        types.List `tfsdk:"actions"`
+	NotActions     types.List `tfsdk:"not_actions"`
+	DataActions    types.List `tfsdk:"data_actions"`
+	NotDataActions types.List `tfsdk:"not_data_actions"`
+}
+
+type roleAssignmentData struct {
+	Name               types.String `tfsdk:"name"`
+	RoleDefinitionName types.String `tfsdk:"role_definition_name"`
+	RoleDefinitionId   types.String `tfsdk:"role_definition_id"`
+	PrincipalId        types.String `tfsdk:"principal_id"`
+	Scope              types.String `tfsdk:"scope"`
+}
+
+type managementGroupConfigData struct {
+	ParentId  types.String `tfsdk:"parent_id"`
+	Archetype types.String `tfsdk:"archetype"`
+}
+
+type managementGroupData struct {
+	Id                   types.String                       `tfsdk:"id"`
+	ParentId             types.String                       `tfsdk:"parent_id"`
+	ResourceId           types.String                       `tfsdk:"resource_id"`
+	Depth                types.Int64                        `tfsdk:"depth"`
+	Archetype            types.String                       `tfsdk:"archetype"`
+	PolicyDefinitions    map[string]policyDefinitionsData   `tfsdk:"policy_definitions"`
+	PolicySetDefinitions map[string]policySetDefinitionData `tfsdk:"policy_set_definitions"`
+	PolicyAssignments    map[string]policyAssignmentData    `tfsdk:"policy_assignments"`
+	RoleDefinitions      map[string]roleDefinitionData      `tfsdk:"role_definitions"`
+	RoleAssignments      map[string]roleAssignmentData      `tfsdk:"role_assignments"`
 }
@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = hygieneDataSourceType{}
+var _ tfsdk.DataSource = hygieneDataSource{}
+
+type hygieneDataSourceType struct{}
+
+func (t hygieneDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	checks := make([]string, len(alzlib.HygieneChecks))
+	for i, c := range alzlib.HygieneChecks {
+		checks[i] = "`" + string(c) + "`"
+	}
+
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "A hygiene report of the library: the policy definitions, policy set definitions, policy assignments and role definitions " +
+			"that are not in any archetype, the JSON files that were ignored because their names do not start with a lib file prefix, " +
+			"e.g. `policy_defintion_x.json`, and the archetypes that are empty. " +
+			"Findings with the `error` severity fail the data source and findings with the `warning` severity are reported as warnings.",
+
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "A fingerprint of the `findings`, which changes when the findings change",
+				Type:                types.StringType,
+				Computed:            true,
+			},
+			"severities": {
+				MarkdownDescription: "The severity of each check, keyed by check, which is one of " + strings.Join(checks, ", ") + ". " +
+					"The severity is `error`, `warning`, `info` or `off`, which disables the check. " +
+					"Checks that are not set have the `" + string(alzlib.DefaultHygieneSeverity) + "` severity.",
+				Optional: true,
+				Type:     types.MapType{ElemType: types.StringType},
+			},
+			"findings": {
+				MarkdownDescription: "The findings, sorted by check and then by name. " +
+					"The `file` is the path of the lib file, the `name` is the name of the object, or the path of the file for `unknown_prefix` findings, " +
+					"and the `message` describes the finding.",
+				Computed: true,
+				Type:     types.ListType{ElemType: hygieneFindingType()},
+			},
+		},
+	}, nil
+}
+
+func hygieneFindingType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"check":    types.StringType,
+			"severity": types.StringType,
+			"file":     types.StringType,
+			"name":     types.StringType,
+			"message":  types.StringType,
+		},
+	}
+}
+
+func (t hygieneDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return hygieneDataSource{
+		provider: provider,
+	}, diags
+}
+
+type hygieneDataSource struct {
+	provider provider
+}
+
+type hygieneDataSourceData struct {
+	Id         types.String         `tfsdk:"id"`
+	Severities types.Map            `tfsdk:"severities"`
+	Findings   []hygieneFindingData `tfsdk:"findings"`
+}
+
+type hygieneFindingData struct {
+	Check    types.String `tfsdk:"check"`
+	Severity types.String `tfsdk:"severity"`
+	File     types.String `tfsdk:"file"`
+	Name     types.String `tfsdk:"name"`
+	Message  types.String `tfsdk:"message"`
+}
+
+func (d hygieneDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	var data hygieneDataSourceData
+
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	severities, diags := hygieneSeveritiesFromMap(ctx, data.Severities)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	findings := d.provider.client.Hygiene(severities)
+	resp.Diagnostics.Append(hygieneDiagnostics(findings)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Findings = make([]hygieneFindingData, len(findings))
+	for i, f := range findings {
+		data.Findings[i] = hygieneFindingData{
+			Check:    types.String{Value: string(f.Check)},
+			Severity: types.String{Value: string(f.Severity)},
+			File:     types.String{Value: f.File},
+			Name:     types.String{Value: f.Name},
+			Message:  types.String{Value: f.Message},
+		}
+	}
+
+	fingerprint, err := alzlib.Fingerprint(findings)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading library hygiene", err.Error())
+		return
+	}
+	data.Id = types.String{Value: fingerprint}
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// hygieneSeveritiesFromMap returns the severities in the supplied map, which is keyed by hygiene check
+func hygieneSeveritiesFromMap(ctx context.Context, m types.Map) (map[alzlib.HygieneCheck]alzlib.Severity, diag.Diagnostics) {
+	if m.Null || m.Unknown {
+		return nil, nil
+	}
+	raw := make(map[string]string, len(m.Elems))
+	diags := m.ElementsAs(ctx, &raw, false)
+	if diags.HasError() {
+		return nil, diags
+	}
+	result := make(map[alzlib.HygieneCheck]alzlib.Severity, len(raw))
+	for k, v := range raw {
+		path := tftypes.NewAttributePath().WithAttributeName("severities").WithElementKeyString(k)
+		check, err := alzlib.ParseHygieneCheck(k)
+		if err != nil {
+			diags.AddAttributeError(path, "Invalid hygiene check", err.Error())
+			continue
+		}
+		sev, err := alzlib.ParseSeverity(v)
+		if err != nil {
+			diags.AddAttributeError(path, "Invalid hygiene severity", err.Error())
+			continue
+		}
+		result[check] = sev
+	}
+	return result, diags
+}
+
+// hygieneDiagnostics returns an error diagnostic for each finding with the error severity
+// and a warning diagnostic for each finding with the warning severity
+func hygieneDiagnostics(findings []alzlib.HygieneFinding) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, f := range findings {
+		switch f.Severity {
+		case alzlib.SeverityError:
+			diags.AddError("alzlib library hygiene", f.String())
+		case alzlib.SeverityWarning:
+			diags.AddWarning("alzlib library hygiene", f.String())
+		}
+	}
+	return diags
+}
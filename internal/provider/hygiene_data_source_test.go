@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+func TestAccHygieneDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccHygieneDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.alzlib_hygiene.test", "findings.0.check", string(alzlib.HygieneEmptyArchetype)),
+					resource.TestCheckResourceAttr("data.alzlib_hygiene.test", "findings.0.severity", string(alzlib.SeverityInfo)),
+					resource.TestMatchResourceAttr("data.alzlib_hygiene.test", "id", sha256Regex),
+				),
+			},
+		},
+	})
+}
+
+const testAccHygieneDataSourceConfig = `
+data "alzlib_hygiene" "test" {
+  severities = {
+    unreferenced_policy_definition     = "off"
+    unreferenced_policy_set_definition = "off"
+    unreferenced_policy_assignment     = "off"
+    unreferenced_role_definition       = "off"
+    unknown_prefix                     = "off"
+    empty_archetype                    = "info"
+  }
+}
+`
+
+func TestHygieneSeveritiesFromMap(t *testing.T) {
+	m := types.Map{ElemType: types.StringType, Elems: map[string]attr.Value{
+		"empty_archetype": types.String{Value: "off"},
+		"unknown_prefix":  types.String{Value: "error"},
+	}}
+	severities, diags := hygieneSeveritiesFromMap(context.Background(), m)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if severities[alzlib.HygieneEmptyArchetype] != alzlib.SeverityOff || severities[alzlib.HygieneUnknownPrefix] != alzlib.SeverityError {
+		t.Errorf("unexpected severities %v", severities)
+	}
+
+	m.Elems = map[string]attr.Value{
+		"empty":          types.String{Value: "off"},
+		"unknown_prefix": types.String{Value: "fatal"},
+	}
+	if _, diags := hygieneSeveritiesFromMap(context.Background(), m); len(diags) != 2 {
+		t.Errorf("expected a diagnostic for the unknown check and the unknown severity, got %v", diags)
+	}
+}
+
+func TestHygieneDiagnostics(t *testing.T) {
+	diags := hygieneDiagnostics([]alzlib.HygieneFinding{
+		{Check: alzlib.HygieneEmptyArchetype, Severity: alzlib.SeverityError, Name: "a", Message: "archetype a is empty"},
+		{Check: alzlib.HygieneEmptyArchetype, Severity: alzlib.SeverityWarning, Name: "b", Message: "archetype b is empty"},
+		{Check: alzlib.HygieneEmptyArchetype, Severity: alzlib.SeverityInfo, Name: "c", Message: "archetype c is empty"},
+	})
+	if len(diags) != 2 || diags[0].Severity() != diag.SeverityError || diags[1].Severity() != diag.SeverityWarning {
+		t.Errorf("expected an error and a warning diagnostic, got %v", diags)
+	}
+	if diags[0].Detail() != "archetype a is empty (empty_archetype)" {
+		t.Errorf("unexpected detail %s", diags[0].Detail())
+	}
+}
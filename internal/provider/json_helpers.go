@@ -1,21 +1,14 @@
 package provider
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"reflect"
+)
 
-func flattenJSON(stringMap interface{}) string {
-	if stringMap != nil {
-		value := stringMap.(map[string]interface{})
-		jsonString, err := flattenJsonToString(value)
-		if err == nil {
-			return jsonString
-		}
-	}
-
-	return ""
-}
-
-func flattenJsonToString(input map[string]interface{}) (string, error) {
-	if len(input) == 0 {
+// flattenValueToString returns the compact JSON representation of the supplied value,
+// or an empty string if the value is nil.
+func flattenValueToString(input interface{}) (string, error) {
+	if input == nil || reflect.ValueOf(input).IsZero() {
 		return "", nil
 	}
 
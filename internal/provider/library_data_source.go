@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/library"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = libraryDataSourceType{}
+var _ tfsdk.DataSource = libraryDataSource{}
+
+type libraryDataSourceType struct{}
+
+func (t libraryDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Information about the library that the provider has loaded",
+
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "A fingerprint of the whole library, which changes when the content of any library object changes",
+				Type:                types.StringType,
+				Computed:            true,
+			},
+			"embedded_version": {
+				MarkdownDescription: "The version of the library that is embedded in the provider, the tag of `github.com/matt-FFFFFF/alzlib` that it was copied from",
+				Computed:            true,
+				Type:                types.StringType,
+			},
+			"embedded": {
+				MarkdownDescription: "Whether the embedded library is in use",
+				Computed:            true,
+				Type:                types.BoolType,
+			},
+			"layers": {
+				MarkdownDescription: "The library layers, in the order that they are processed. " +
+					"The embedded library layer is named `" + library.LayerName + "`.",
+				Computed: true,
+				Type:     types.ListType{ElemType: types.StringType},
+			},
+		},
+	}, nil
+}
+
+func (t libraryDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return libraryDataSource{
+		provider: provider,
+	}, diags
+}
+
+type libraryDataSource struct {
+	provider provider
+}
+
+type libraryDataSourceData struct {
+	Id              types.String `tfsdk:"id"`
+	EmbeddedVersion types.String `tfsdk:"embedded_version"`
+	Embedded        types.Bool   `tfsdk:"embedded"`
+	Layers          types.List   `tfsdk:"layers"`
+}
+
+func (d libraryDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	data := libraryDataSourceData{
+		EmbeddedVersion: types.String{Value: library.Version},
+		Embedded:        types.Bool{Value: d.provider.embeddedLibrary},
+		Layers:          types.List{ElemType: types.StringType, Elems: []attr.Value{}},
+	}
+
+	fingerprint, err := d.provider.client.Fingerprint()
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading library", err.Error())
+		return
+	}
+	data.Id = types.String{Value: fingerprint}
+
+	for _, l := range d.provider.client.Layers() {
+		data.Layers.Elems = append(data.Layers.Elems, types.String{Value: l})
+	}
+
+	diags := resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
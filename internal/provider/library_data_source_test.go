@@ -0,0 +1,269 @@
+package provider
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/bundle"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/library"
+)
+
+func TestAccLibraryDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccLibraryDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.alzlib_library.test", "embedded_version", library.Version),
+					resource.TestCheckResourceAttr("data.alzlib_library.test", "embedded", "false"),
+					resource.TestCheckResourceAttr("data.alzlib_library.test", "layers.#", "1"),
+					resource.TestCheckResourceAttr("data.alzlib_library.test", "layers.0", os.Getenv("ALZLIB_DIR")),
+					resource.TestMatchResourceAttr("data.alzlib_library.test", "id", sha256Regex),
+				),
+			},
+		},
+	})
+}
+
+const testAccLibraryDataSourceConfig = `
+data "alzlib_library" "test" {}
+`
+
+func TestLibraryLayers(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "lib.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zip.NewWriter(f).Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cases := []struct {
+		name     string
+		mode     string
+		dirs     []string
+		checksum string
+		layers   []string
+		err      bool
+	}{
+		{name: "default embedded", layers: []string{library.LayerName}},
+		{name: "default directory", dirs: []string{testLibDir}, layers: []string{testLibDir}},
+		{name: "embedded ignores directories", mode: libraryModeEmbedded, dirs: []string{testLibDir}, layers: []string{library.LayerName}},
+		{name: "layered", mode: libraryModeLayered, dirs: []string{testLibDir}, layers: []string{library.LayerName, testLibDir}},
+		{name: "directory without directories", mode: libraryModeDirectory, err: true},
+		{name: "invalid mode", mode: "invalid", err: true},
+		{name: "missing archive", dirs: []string{"../../testdata/doesnotexist.zip"}, err: true},
+		{name: "archive checksum mismatch", dirs: []string{archive}, checksum: "0000", err: true},
+		{name: "archive", dirs: []string{archive}, layers: []string{archive}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			layers, diags := libraryLayers(resolveLibraryMode(c.mode, c.dirs), c.dirs, c.checksum)
+			if diags.HasError() != c.err {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if len(layers) != len(c.layers) {
+				t.Fatalf("expected %d layers, got %d", len(c.layers), len(layers))
+			}
+			for i, l := range layers {
+				if l.Name != c.layers[i] {
+					t.Errorf("expected layer %d to be %s, got %s", i, c.layers[i], l.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestWalkOptionsFromData(t *testing.T) {
+	ctx := context.Background()
+	data := providerData{
+		IncludePatterns: types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "**/*.json"}}},
+		ExcludePatterns: types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "drafts"}, types.String{Value: "[a-"}}},
+		FollowSymlinks:  types.Bool{Value: true},
+		IncludeHidden:   types.Bool{Null: true},
+	}
+	opts, diags := walkOptionsFromData(ctx, data)
+	if len(diags) != 1 || !diags[0].(diag.DiagnosticWithPath).Path().Equal(tftypes.NewAttributePath().WithAttributeName("exclude_patterns").WithElementKeyInt(1)) {
+		t.Fatalf("expected an error for the invalid exclude pattern, got %v", diags)
+	}
+	if !opts.FollowSymlinks || opts.IncludeHidden || len(opts.Include) != 1 || len(opts.Exclude) != 2 {
+		t.Errorf("unexpected walk options %+v", opts)
+	}
+
+	layers := []alzlib.LibLayer{library.Layer(), {Name: testLibDir}}
+	setWalkOptions(ctx, layers, opts)
+	if len(layers[0].Walk.Include) != 0 || layers[0].Walk.Log == nil {
+		t.Errorf("expected the embedded library to be read in full and logged, got %+v", layers[0].Walk)
+	}
+	if len(layers[1].Walk.Include) != 1 || !layers[1].Walk.FollowSymlinks || layers[1].Walk.Log == nil {
+		t.Errorf("expected the walk options to be set on the directory layer, got %+v", layers[1].Walk)
+	}
+}
+
+func TestUseParseCache(t *testing.T) {
+	keys := types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "key"}}}
+	noKeys := types.List{ElemType: types.StringType, Null: true}
+	lockFile := types.String{Value: ".alzlib.lock.json"}
+	noLockFile := types.String{Null: true}
+	testCases := []struct {
+		name       string
+		parseCache types.Bool
+		keys       types.List
+		lockFile   types.String
+		want       bool
+		warnings   int
+	}{
+		{name: "default", parseCache: types.Bool{Null: true}, keys: noKeys, lockFile: noLockFile},
+		{name: "disabled", parseCache: types.Bool{Value: false}, keys: noKeys, lockFile: noLockFile},
+		{name: "enabled", parseCache: types.Bool{Value: true}, keys: noKeys, lockFile: noLockFile, want: true},
+		{name: "trusted keys", parseCache: types.Bool{Value: true}, keys: keys, lockFile: noLockFile, warnings: 1},
+		{name: "lock file", parseCache: types.Bool{Value: true}, keys: noKeys, lockFile: lockFile, warnings: 1},
+		{name: "default with trusted keys", parseCache: types.Bool{Null: true}, keys: keys, lockFile: lockFile},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, diags := useParseCache(tc.parseCache, tc.keys, tc.lockFile)
+			if got != tc.want {
+				t.Errorf("expected %t, got %t", tc.want, got)
+			}
+			if diags.HasError() || len(diags) != tc.warnings {
+				t.Errorf("expected %d warnings, got %v", tc.warnings, diags)
+			}
+		})
+	}
+}
+
+func TestSetParseCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	layers := []alzlib.LibLayer{library.Layer(), {Name: testLibDir}}
+	if diags := setParseCache(layers, dir); diags.HasError() || len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	for _, l := range layers {
+		if l.Cache == nil {
+			t.Errorf("expected layer %s to have a parse cache", l.Name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, parseCacheDir)); err != nil {
+		t.Errorf("expected the parse cache directory to be created: %s", err)
+	}
+
+	// a file is not a valid cache directory, so the cache is disabled with a warning
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	layers = []alzlib.LibLayer{library.Layer()}
+	diags := setParseCache(layers, file)
+	if diags.HasError() || len(diags) != 1 || layers[0].Cache != nil {
+		t.Errorf("expected a warning and no parse cache, got %v", diags)
+	}
+}
+
+// TestVerifyLibraryFiles tests that the lib files are checked against the manifest after they are loaded,
+// so that a file that is changed after the manifest is read is not trusted
+func TestVerifyLibraryFiles(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	src := "../alzlib/testdata/layers/base"
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(src, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, e.Name()), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// files that are not lib files are signed but not loaded, and hidden directories are neither signed nor loaded
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("lib"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git", "policy_definition_x.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pub, priv, err := bundle.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := bundle.ParsePrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := alzlib.DirLayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes, err := alzlib.HashLayer(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := bundle.Pack(hashes).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, bundle.ManifestFileName), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, bundle.SignatureFileName), bundle.Sign(data, key), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	layers := []alzlib.LibLayer{library.Layer(), l}
+	keys := types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: pub}}}
+	manifests, diags := readLibraryManifests(ctx, layers, keys)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if manifests[0] != nil || manifests[1] == nil {
+		t.Fatalf("expected a manifest for the directory layer only, got %v", manifests)
+	}
+	az, err := alzlib.NewAlzLibFromLayers(layers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := verifyLibraryFiles(layers, manifests, az.Files()); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	// the file is changed after the manifest has been read, but before the library is loaded
+	if err := os.WriteFile(filepath.Join(dir, "policy_definition_test_policy.json"), []byte(`{"name": "test-policy"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	az, err = alzlib.NewAlzLibFromLayers(layers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags = verifyLibraryFiles(layers, manifests, az.Files())
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail(), "files changed since the manifest was signed: policy_definition_test_policy.json") {
+		t.Errorf("expected the changed file to be reported, got %v", diags)
+	}
+
+	keys = types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "invalid"}}}
+	if _, diags := readLibraryManifests(ctx, layers, keys); !diags.HasError() {
+		t.Error("expected an error for the invalid trusted key")
+	}
+}
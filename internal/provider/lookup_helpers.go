@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agext/levenshtein"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// maxSuggestions is the maximum number of close matches listed in a "not found" diagnostic
+const maxSuggestions = 3
+
+// lookupAttributes returns the schema attributes of a single object lookup data source.
+// The object attributes are computed, apart from `name`, which is required and is used to look up the object.
+func lookupAttributes(objType types.MapType, nameDescription string) map[string]tfsdk.Attribute {
+	attrs := map[string]tfsdk.Attribute{
+		"id": {
+			MarkdownDescription: "A fingerprint of the object, which changes when its content changes",
+			Type:                types.StringType,
+			Computed:            true,
+		},
+		"name": {
+			MarkdownDescription: nameDescription,
+			Required:            true,
+			Type:                types.StringType,
+		},
+		"template_variables": {
+			MarkdownDescription: "Values used to render the `${...}` placeholders in the lib file for this data source, " +
+				"e.g. `current_scope_id`. These are merged over the provider `template_variables`.",
+			Optional: true,
+			Type:     types.MapType{ElemType: types.StringType},
+		},
+	}
+	for k, v := range objType.ElemType.(types.ObjectType).AttrTypes {
+		if _, exists := attrs[k]; exists {
+			continue
+		}
+		attrs[k] = tfsdk.Attribute{
+			Computed: true,
+			Type:     v,
+		}
+	}
+	return attrs
+}
+
+// closeMatches returns the candidates that are most similar to the supplied name,
+// closest first, for use in "not found" diagnostics
+func closeMatches[T any](name string, candidates map[string]T) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+	lname := strings.ToLower(name)
+	threshold := len(name) / 3
+	if threshold < 3 {
+		threshold = 3
+	}
+	matches := make([]match, 0)
+	for c := range candidates {
+		lc := strings.ToLower(c)
+		d := levenshtein.Distance(lname, lc, nil)
+		if d > threshold && !strings.Contains(lc, lname) {
+			continue
+		}
+		matches = append(matches, match{name: c, distance: d})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+	result := make([]string, 0, maxSuggestions)
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		result = append(result, matches[i].name)
+	}
+	return result
+}
+
+// addNotFoundDiagnostic adds an error to the `name` attribute,
+// suggesting close matches from the supplied candidates
+func addNotFoundDiagnostic[T any](diags *diag.Diagnostics, kind, name string, candidates map[string]T) {
+	detail := fmt.Sprintf("The %s %q was not found in the library.", kind, name)
+	if suggestions := closeMatches(name, candidates); len(suggestions) > 0 {
+		quoted := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			quoted[i] = strconv.Quote(s)
+		}
+		detail += fmt.Sprintf(" Did you mean %s?", strings.Join(quoted, " or "))
+	}
+	diags.AddAttributeError(
+		tftypes.NewAttributePath().WithAttributeName("name"),
+		fmt.Sprintf("%s not found", strings.ToUpper(kind[:1])+kind[1:]),
+		detail,
+	)
+}
@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCloseMatches(t *testing.T) {
+	candidates := map[string]struct{}{
+		"Deny-Subnet-Without-Nsg": {},
+		"Deny-Subnet-Without-Udr": {},
+		"Deploy-MDFC-Config":      {},
+	}
+	got := closeMatches("deny-subnet-without-nsgs", candidates)
+	want := []string{"Deny-Subnet-Without-Nsg", "Deny-Subnet-Without-Udr"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("closeMatches() = %v, want %v", got, want)
+	}
+	if got := closeMatches("Something-Else", candidates); len(got) != 0 {
+		t.Errorf("closeMatches() = %v, want no matches", got)
+	}
+}
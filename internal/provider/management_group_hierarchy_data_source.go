@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = managementGroupHierarchyDataSourceType{}
+var _ tfsdk.DataSource = managementGroupHierarchyDataSource{}
+
+type managementGroupHierarchyDataSourceType struct{}
+
+func (t managementGroupHierarchyDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Assigns archetypes to a tree of management groups and returns the policy and role resources " +
+			"for each management group, with the scopes rendered for that management group",
+
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "A fingerprint of the `hierarchy`, which changes when its content changes",
+				Type:                types.StringType,
+				Computed:            true,
+			},
+			"root_parent_id": {
+				MarkdownDescription: "The id of the existing management group that the hierarchy is deployed beneath, " +
+					"e.g. the tenant root group",
+				Required: true,
+				Type:     types.StringType,
+			},
+			"management_groups": {
+				MarkdownDescription: "The management groups in the hierarchy, keyed by management group id. " +
+					"The `parent_id` must be another management group in the hierarchy or the `root_parent_id`.",
+				Required: true,
+				Type: types.MapType{
+					ElemType: types.ObjectType{
+						AttrTypes: map[string]attr.Type{
+							"parent_id": types.StringType,
+							"archetype": types.StringType,
+						},
+					},
+				},
+			},
+			"template_variables": {
+				MarkdownDescription: "Values used to render the `${...}` placeholders in the lib files for this data source, " +
+					"e.g. `default_location`. These are merged over the provider `template_variables`. " +
+					"The `root_scope_id` defaults to the top-level management group and the `current_scope_id` is always " +
+					"the management group being rendered.",
+				Optional: true,
+				Type:     types.MapType{ElemType: types.StringType},
+			},
+			"hierarchy": {
+				MarkdownDescription: "The rendered management groups, keyed by management group id",
+				Computed:            true,
+				Type: types.MapType{
+					ElemType: managementGroupType(),
+				},
+			},
+		},
+	}, nil
+}
+
+func managementGroupType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"id":                     types.StringType,
+			"parent_id":              types.StringType,
+			"resource_id":            types.StringType,
+			"depth":                  types.Int64Type,
+			"archetype":              types.StringType,
+			"policy_definitions":     policyDefinitionType(),
+			"policy_set_definitions": policySetDefinitionType(),
+			"policy_assignments":     policyAssignmentType(),
+			"role_definitions":       roleDefinitionType(),
+			"role_assignments":       roleAssignmentType(),
+		},
+	}
+}
+
+func (t managementGroupHierarchyDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return managementGroupHierarchyDataSource{
+		provider: provider,
+	}, diags
+}
+
+type managementGroupHierarchyDataSource struct {
+	provider provider
+}
+
+type managementGroupHierarchyDataSourceData struct {
+	Id                types.String                         `tfsdk:"id"`
+	RootParentId      types.String                         `tfsdk:"root_parent_id"`
+	ManagementGroups  map[string]managementGroupConfigData `tfsdk:"management_groups"`
+	TemplateVariables types.Map                            `tfsdk:"template_variables"`
+	Hierarchy         map[string]managementGroupData       `tfsdk:"hierarchy"`
+}
+
+func (d managementGroupHierarchyDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	var data managementGroupHierarchyDataSourceData
+
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	dsVars, diags := templateVariablesFromMap(ctx, data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	vars := mergeTemplateVariables(d.provider.templateVariables, dsVars)
+
+	// Sort the ids so that the input to the hierarchy, and therefore any errors, are deterministic
+	ids := make([]string, 0, len(data.ManagementGroups))
+	for id := range data.ManagementGroups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	mgs := make([]alzlib.ManagementGroup, 0, len(ids))
+	for _, id := range ids {
+		mgs = append(mgs, alzlib.ManagementGroup{
+			Id:        id,
+			ParentId:  data.ManagementGroups[id].ParentId.Value,
+			Archetype: data.ManagementGroups[id].Archetype.Value,
+		})
+	}
+
+	h, errs := d.provider.client.NewHierarchy(data.RootParentId.Value, mgs)
+	resp.Diagnostics.Append(hierarchyDiagnostics(errs)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Hierarchy = make(map[string]managementGroupData, len(h.ManagementGroups))
+	hashes := make(map[string]map[string]string, len(h.ManagementGroups))
+	for id, mg := range h.ManagementGroups {
+		path := tftypes.NewAttributePath().WithAttributeName("hierarchy").WithElementKeyString(id)
+		ad := newArchetypeData(d.provider.client, mg.Archetype, d.provider.client.Archetypes[mg.Archetype], h.TemplateVariables(id, vars), path, &resp.Diagnostics)
+		data.Hierarchy[id] = managementGroupData{
+			Id:                   types.String{Value: id},
+			ParentId:             types.String{Value: mg.ParentId},
+			ResourceId:           types.String{Value: h.ResourceId(id)},
+			Depth:                types.Int64{Value: int64(mg.Depth)},
+			Archetype:            types.String{Value: mg.Archetype},
+			PolicyDefinitions:    ad.PolicyDefinitions,
+			PolicySetDefinitions: ad.PolicySetDefinitions,
+			PolicyAssignments:    ad.PolicyAssignments,
+			RoleDefinitions:      ad.RoleDefinitions,
+			RoleAssignments:      ad.RoleAssignments,
+		}
+		hashes[id] = map[string]string{
+			"parent_id":    mg.ParentId,
+			"archetype":    mg.Archetype,
+			"content_hash": ad.ContentHash.Value,
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, err := contentHash(hashes)
+	if err != nil {
+		resp.Diagnostics.AddError("Error generating management group hierarchy", err.Error())
+		return
+	}
+	data.Id = id
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// hierarchyDiagnostics returns the errors from creating a hierarchy as diagnostics,
+// at the management group that each error refers to
+func hierarchyDiagnostics(errs []error) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, err := range errs {
+		path := tftypes.NewAttributePath().WithAttributeName("management_groups")
+		var herr *alzlib.HierarchyError
+		if errors.As(err, &herr) {
+			path = path.WithElementKeyString(herr.Id)
+		}
+		diags.AddAttributeError(path, "Invalid management group hierarchy", err.Error())
+	}
+	return diags
+}
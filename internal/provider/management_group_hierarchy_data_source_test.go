@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+func TestAccManagementGroupHierarchyDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccManagementGroupHierarchyDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.alzlib_management_group_hierarchy.test", "hierarchy.%", "3"),
+					resource.TestCheckResourceAttr("data.alzlib_management_group_hierarchy.test", "hierarchy.alz.depth", "1"),
+					resource.TestCheckResourceAttr("data.alzlib_management_group_hierarchy.test", "hierarchy.alz-corp.depth", "3"),
+					resource.TestCheckResourceAttr("data.alzlib_management_group_hierarchy.test", "hierarchy.alz-corp.archetype", "es_corp"),
+					resource.TestCheckResourceAttr("data.alzlib_management_group_hierarchy.test", "hierarchy.alz-corp.resource_id", "/providers/Microsoft.Management/managementGroups/alz-corp"),
+					resource.TestCheckResourceAttr("data.alzlib_management_group_hierarchy.test", "hierarchy.alz-corp.policy_assignments.Deny-DataB-Pip.scope", "/providers/Microsoft.Management/managementGroups/alz-corp"),
+					resource.TestCheckResourceAttr("data.alzlib_management_group_hierarchy.test", "hierarchy.alz.policy_definitions.%", "104"),
+					resource.TestCheckResourceAttr("data.alzlib_management_group_hierarchy.test", "hierarchy.alz.role_definitions.Application-Owners.assignable_scopes.0", "/providers/Microsoft.Management/managementGroups/alz"),
+				),
+			},
+			// Invalid hierarchy
+			{
+				Config:      testAccManagementGroupHierarchyDataSourceConfigInvalid,
+				ExpectError: regexp.MustCompile("management group alz-corp has unknown archetype: es_unknown"),
+			},
+		},
+	})
+}
+
+// TestHierarchyDiagnostics tests that the hierarchy errors are reported at the management group that they refer to
+func TestHierarchyDiagnostics(t *testing.T) {
+	diags := hierarchyDiagnostics([]error{
+		&alzlib.HierarchyError{Id: "alz-corp", Message: "management group alz-corp has unknown archetype: es_unknown"},
+		errors.New("other error"),
+	})
+	want := []*tftypes.AttributePath{
+		tftypes.NewAttributePath().WithAttributeName("management_groups").WithElementKeyString("alz-corp"),
+		tftypes.NewAttributePath().WithAttributeName("management_groups"),
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), diags)
+	}
+	for i, d := range diags {
+		if d.Severity() != diag.SeverityError || !d.(diag.DiagnosticWithPath).Path().Equal(want[i]) {
+			t.Errorf("expected an error at %s, got %v", want[i], d)
+		}
+	}
+}
+
+const testAccManagementGroupHierarchyDataSourceConfig = `
+data "alzlib_management_group_hierarchy" "test" {
+  root_parent_id = "tenant"
+
+  management_groups = {
+    alz = {
+      parent_id = "tenant"
+      archetype = "es_root"
+    }
+    alz-landing-zones = {
+      parent_id = "alz"
+      archetype = "es_landing_zones"
+    }
+    alz-corp = {
+      parent_id = "alz-landing-zones"
+      archetype = "es_corp"
+    }
+  }
+
+  template_variables = {
+    default_location        = "uksouth"
+    private_dns_zone_prefix = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/dns/providers/Microsoft.Network/privateDnsZones/"
+  }
+}
+`
+
+const testAccManagementGroupHierarchyDataSourceConfigInvalid = `
+data "alzlib_management_group_hierarchy" "test" {
+  root_parent_id = "tenant"
+
+  management_groups = {
+    alz = {
+      parent_id = "tenant"
+      archetype = "es_root"
+    }
+    alz-corp = {
+      parent_id = "alz"
+      archetype = "es_unknown"
+    }
+  }
+}
+`
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = policyAssignmentDataSourceType{}
+var _ tfsdk.DataSource = policyAssignmentDataSource{}
+
+type policyAssignmentDataSourceType struct{}
+
+func (t policyAssignmentDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "A single policy assignment from the library, looked up by name",
+
+		Attributes: lookupAttributes(policyAssignmentType(), "The name of the policy assignment"),
+	}, nil
+}
+
+func (t policyAssignmentDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return policyAssignmentDataSource{
+		provider: provider,
+	}, diags
+}
+
+type policyAssignmentDataSource struct {
+	provider provider
+}
+
+type policyAssignmentDataSourceData struct {
+	Id                 types.String `tfsdk:"id"`
+	Name               types.String `tfsdk:"name"`
+	Layer              types.String `tfsdk:"layer"`
+	ContentHash        types.String `tfsdk:"content_hash"`
+	TemplateVariables  types.Map    `tfsdk:"template_variables"`
+	DisplayName        types.String `tfsdk:"display_name"`
+	Description        types.String `tfsdk:"description"`
+	PolicyDefinitionId types.String `tfsdk:"policy_definition_id"`
+	Scope              types.String `tfsdk:"scope"`
+	NotScopes          types.List   `tfsdk:"not_scopes"`
+	EnforcementMode    types.String `tfsdk:"enforcement_mode"`
+	IdentityType       types.String `tfsdk:"identity_type"`
+	Location           types.String `tfsdk:"location"`
+	Parameters         types.String `tfsdk:"parameters"`
+}
+
+func (d policyAssignmentDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	var data policyAssignmentDataSourceData
+
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	dsVars, diags := templateVariablesFromMap(ctx, data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	vars := mergeTemplateVariables(d.provider.templateVariables, dsVars)
+
+	name := data.Name.Value
+	pa, ok := d.provider.client.PolicyAssignments[name]
+	if !ok {
+		addNotFoundDiagnostic(&resp.Diagnostics, "policy assignment", name, d.provider.client.PolicyAssignments)
+		return
+	}
+
+	pav := *pa
+	if vars != nil {
+		rendered, err := d.provider.client.RenderPolicyAssignment(pav, vars)
+		if err != nil {
+			addRenderErrorDiagnostic(&resp.Diagnostics, err)
+			return
+		}
+		pav = *rendered
+	}
+
+	pad, err := newPolicyAssignmentData(name, pav)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading policy assignment", fmt.Sprintf("Unable to read policy assignment %s: %s", name, err))
+		return
+	}
+
+	data.Id = pad.ContentHash
+	data.Layer = layerValue(d.provider.client, alzlib.KindPolicyAssignment, name)
+	data.ContentHash = pad.ContentHash
+	data.DisplayName = pad.DisplayName
+	data.Description = pad.Description
+	data.PolicyDefinitionId = pad.PolicyDefinitionId
+	data.Scope = pad.Scope
+	data.NotScopes = pad.NotScopes
+	data.EnforcementMode = pad.EnforcementMode
+	data.IdentityType = pad.IdentityType
+	data.Location = pad.Location
+	data.Parameters = pad.Parameters
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccPolicyAssignmentDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccPolicyAssignmentDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("data.alzlib_policy_assignment.test", "id", sha256Regex),
+					resource.TestCheckResourceAttr("data.alzlib_policy_assignment.test", "scope", "/providers/Microsoft.Management/managementGroups/alz-corp"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_assignment.test", "location", "uksouth"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_assignment.test", "parameters", `{"effect":{"value":"Deny"}}`),
+				),
+			},
+		},
+	})
+}
+
+const testAccPolicyAssignmentDataSourceConfig = `
+data "alzlib_policy_assignment" "test" {
+  name = "Deny-DataB-Pip"
+  template_variables = {
+    root_scope_id    = "alz"
+    current_scope_id = "alz-corp"
+    default_location = "uksouth"
+  }
+}
+`
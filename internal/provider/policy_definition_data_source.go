@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = policyDefinitionDataSourceType{}
+var _ tfsdk.DataSource = policyDefinitionDataSource{}
+
+type policyDefinitionDataSourceType struct{}
+
+func (t policyDefinitionDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "A single policy definition from the library, looked up by name",
+
+		Attributes: lookupAttributes(policyDefinitionType(), "The name of the policy definition"),
+	}, nil
+}
+
+func (t policyDefinitionDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return policyDefinitionDataSource{
+		provider: provider,
+	}, diags
+}
+
+type policyDefinitionDataSource struct {
+	provider provider
+}
+
+type policyDefinitionDataSourceData struct {
+	Id                types.String `tfsdk:"id"`
+	Name              types.String `tfsdk:"name"`
+	Layer             types.String `tfsdk:"layer"`
+	ContentHash       types.String `tfsdk:"content_hash"`
+	TemplateVariables types.Map    `tfsdk:"template_variables"`
+	ResourceId        types.String `tfsdk:"resource_id"`
+	ResourceType      types.String `tfsdk:"resource_type"`
+	DisplayName       types.String `tfsdk:"display_name"`
+	PolicyType        types.String `tfsdk:"policy_type"`
+	Mode              types.String `tfsdk:"mode"`
+	Description       types.String `tfsdk:"description"`
+	Version           types.String `tfsdk:"version"`
+	Category          types.String `tfsdk:"category"`
+	PolicyRule        types.String `tfsdk:"policy_rule"`
+	Metadata          types.String `tfsdk:"metadata"`
+	Parameters        types.String `tfsdk:"parameters"`
+}
+
+func (d policyDefinitionDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	var data policyDefinitionDataSourceData
+
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	dsVars, diags := templateVariablesFromMap(ctx, data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	vars := mergeTemplateVariables(d.provider.templateVariables, dsVars)
+
+	name := data.Name.Value
+	pd, ok := d.provider.client.PolicyDefinitions[name]
+	if !ok {
+		addNotFoundDiagnostic(&resp.Diagnostics, "policy definition", name, d.provider.client.PolicyDefinitions)
+		return
+	}
+
+	pdv := *pd
+	if vars != nil {
+		rendered, err := d.provider.client.RenderPolicyDefinition(pdv, vars)
+		if err != nil {
+			addRenderErrorDiagnostic(&resp.Diagnostics, err)
+			return
+		}
+		pdv = *rendered
+	}
+
+	pdd, diags := newPolicyDefinitionData(name, pdv, tftypes.NewAttributePath())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = pdd.ContentHash
+	data.Layer = layerValue(d.provider.client, alzlib.KindPolicyDefinition, name)
+	data.ContentHash = pdd.ContentHash
+	data.ResourceId = pdd.ResourceId
+	data.ResourceType = pdd.ResourceType
+	data.DisplayName = pdd.DisplayName
+	data.PolicyType = pdd.PolicyType
+	data.Mode = pdd.Mode
+	data.Description = pdd.Description
+	data.Version = pdd.Version
+	data.Category = pdd.Category
+	data.PolicyRule = pdd.PolicyRule
+	data.Metadata = pdd.Metadata
+	data.Parameters = pdd.Parameters
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccPolicyDefinitionDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccPolicyDefinitionDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("data.alzlib_policy_definition.test", "id", sha256Regex),
+					resource.TestCheckResourceAttr("data.alzlib_policy_definition.test", "display_name", "Subnets should have a Network Security Group"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_definition.test", "mode", "All"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_definition.test", "policy_type", "Custom"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_definition.test", "resource_type", "Microsoft.Authorization/policyDefinitions"),
+					// the lib file does not have an id
+					resource.TestCheckNoResourceAttr("data.alzlib_policy_definition.test", "resource_id"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_definition.test", "version", "2.0.0"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_definition.test", "category", "Network"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_definition.test", "layer", os.Getenv("ALZLIB_DIR")),
+				),
+			},
+			// Not found, with a suggestion
+			{
+				Config:      testAccPolicyDefinitionDataSourceConfigNotFound,
+				ExpectError: regexp.MustCompile(`Did you mean "Deny-Subnet-Without-Nsg"`),
+			},
+		},
+	})
+}
+
+const testAccPolicyDefinitionDataSourceConfig = `
+data "alzlib_policy_definition" "test" {
+  name = "Deny-Subnet-Without-Nsg"
+}
+`
+
+const testAccPolicyDefinitionDataSourceConfigNotFound = `
+data "alzlib_policy_definition" "test" {
+  name = "Deny-Subnet-Without-NSG-typo"
+}
+`
@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = policySetDefinitionDataSourceType{}
+var _ tfsdk.DataSource = policySetDefinitionDataSource{}
+
+type policySetDefinitionDataSourceType struct{}
+
+func (t policySetDefinitionDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "A single policy set definition (initiative) from the library, looked up by name",
+
+		Attributes: lookupAttributes(policySetDefinitionType(), "The name of the policy set definition"),
+	}, nil
+}
+
+func (t policySetDefinitionDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return policySetDefinitionDataSource{
+		provider: provider,
+	}, diags
+}
+
+type policySetDefinitionDataSource struct {
+	provider provider
+}
+
+type policySetDefinitionDataSourceData struct {
+	Id                     types.String                    `tfsdk:"id"`
+	Name                   types.String                    `tfsdk:"name"`
+	Layer                  types.String                    `tfsdk:"layer"`
+	ContentHash            types.String                    `tfsdk:"content_hash"`
+	TemplateVariables      types.Map                       `tfsdk:"template_variables"`
+	DisplayName            types.String                    `tfsdk:"display_name"`
+	Description            types.String                    `tfsdk:"description"`
+	PolicyType             types.String                    `tfsdk:"policy_type"`
+	Metadata               types.String                    `tfsdk:"metadata"`
+	Parameters             types.String                    `tfsdk:"parameters"`
+	PolicyDefinitionGroups types.String                    `tfsdk:"policy_definition_groups"`
+	PolicyDefinitions      []policySetDefinitionMemberData `tfsdk:"policy_definitions"`
+}
+
+func (d policySetDefinitionDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	var data policySetDefinitionDataSourceData
+
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	dsVars, diags := templateVariablesFromMap(ctx, data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	vars := mergeTemplateVariables(d.provider.templateVariables, dsVars)
+
+	name := data.Name.Value
+	psd, ok := d.provider.client.PolicySetDefinitions[name]
+	if !ok {
+		addNotFoundDiagnostic(&resp.Diagnostics, "policy set definition", name, d.provider.client.PolicySetDefinitions)
+		return
+	}
+
+	psv := *psd
+	if vars != nil {
+		rendered, err := d.provider.client.RenderPolicySetDefinition(psv, vars)
+		if err != nil {
+			addRenderErrorDiagnostic(&resp.Diagnostics, err)
+			return
+		}
+		psv = *rendered
+	}
+
+	psdd, diags := newPolicySetDefinitionData(name, psv, tftypes.NewAttributePath())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Id = psdd.ContentHash
+	data.Layer = layerValue(d.provider.client, alzlib.KindPolicySetDefinition, name)
+	setResolutionStatus(d.provider.client, name, &psdd)
+	data.ContentHash = psdd.ContentHash
+	data.DisplayName = psdd.DisplayName
+	data.Description = psdd.Description
+	data.PolicyType = psdd.PolicyType
+	data.Metadata = psdd.Metadata
+	data.Parameters = psdd.Parameters
+	data.PolicyDefinitionGroups = psdd.PolicyDefinitionGroups
+	data.PolicyDefinitions = psdd.PolicyDefinitions
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccPolicySetDefinitionDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccPolicySetDefinitionDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("data.alzlib_policy_set_definition.test", "id", sha256Regex),
+					resource.TestCheckResourceAttr("data.alzlib_policy_set_definition.test", "display_name", "Deploy Microsoft Defender for Cloud configuration"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_set_definition.test", "policy_definitions.#", "12"),
+					resource.TestCheckResourceAttr("data.alzlib_policy_set_definition.test", "policy_definitions.0.resolution_status", "unchecked"),
+				),
+			},
+		},
+	})
+}
+
+const testAccPolicySetDefinitionDataSourceConfig = `
+data "alzlib_policy_set_definition" "test" {
+  name = "Deploy-MDFC-Config"
+  template_variables = {
+    root_scope_id = "alz"
+  }
+}
+`
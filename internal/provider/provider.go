@@ -2,13 +2,24 @@ package provider
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/matt-FFFFFF/alzlib"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/bundle"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/library"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/lockfile"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/remote"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces
@@ -23,6 +34,19 @@ type provider struct {
 	//
 	client *alzlib.AlzLib
 
+	// templateVariables are used to render the placeholders in the lib files.
+	// If nil, the lib file contents are returned without rendering.
+	templateVariables alzlib.TemplateVariables
+
+	// findings are the problems that were found in the library, which are only warnings unless the validation mode is warning
+	findings []alzlib.Finding
+
+	// validationMode is the validation_mode of the provider configuration
+	validationMode string
+
+	// embeddedLibrary is true if the embedded library is one of the library layers
+	embeddedLibrary bool
+
 	// configured is set to true at the end of the Configure method.
 	// This can be used in Resource and DataSource implementations to verify
 	// that the provider was previously configured.
@@ -36,7 +60,23 @@ type provider struct {
 
 // providerData can be used to store data from the Terraform configuration.
 type providerData struct {
-	Directory types.String `tfsdk:"directory"`
+	Archive              types.String `tfsdk:"archive"`
+	BuiltInPolicyCatalog types.String `tfsdk:"built_in_policy_catalog"`
+	CacheDir             types.String `tfsdk:"cache_dir"`
+	Directory            types.String `tfsdk:"directory"`
+	Directories          types.List   `tfsdk:"directories"`
+	ExcludePatterns      types.List   `tfsdk:"exclude_patterns"`
+	FollowSymlinks       types.Bool   `tfsdk:"follow_symlinks"`
+	IncludeHidden        types.Bool   `tfsdk:"include_hidden_directories"`
+	IncludePatterns      types.List   `tfsdk:"include_patterns"`
+	LibraryMode          types.String `tfsdk:"library_mode"`
+	LockFile             types.String `tfsdk:"lock_file"`
+	ParseCache           types.Bool   `tfsdk:"parse_cache"`
+	Sha256               types.String `tfsdk:"sha256"`
+	Source               types.String `tfsdk:"source"`
+	TemplateVariables    types.Map    `tfsdk:"template_variables"`
+	TrustedKeys          types.List   `tfsdk:"trusted_keys"`
+	ValidationMode       types.String `tfsdk:"validation_mode"`
 }
 
 func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderRequest, resp *tfsdk.ConfigureProviderResponse) {
@@ -45,30 +85,134 @@ func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderReq
 	resp.Diagnostics.Append(diags...)
 
 	// Initialize the AlzLib client
-	var dir string
-	dir = data.Directory.Value
+	set := 0
+	for _, null := range []bool{data.Archive.Null, data.Directory.Null, data.Directories.Null, data.Source.Null} {
+		if !null {
+			set++
+		}
+	}
+	if set > 1 {
+		resp.Diagnostics.AddError(
+			"conflicting alzlib directory configuration",
+			"Only one of the `archive`, `directory`, `directories` and `source` properties of the provider configuration can be set.",
+		)
+		return
+	}
+	if !data.Sha256.Null && data.Archive.Null && data.Source.Null {
+		resp.Diagnostics.AddAttributeError(
+			tftypes.NewAttributePath().WithAttributeName("sha256"),
+			"sha256 requires an archive",
+			"The `sha256` property of the provider configuration can only be set with the `archive` or `source` properties.",
+		)
+		return
+	}
+
+	var dirs []string
+	if !data.Directory.Null && data.Directory.Value != "" {
+		dirs = []string{data.Directory.Value}
+	}
+	if !data.Directories.Null {
+		diags = data.Directories.ElementsAs(ctx, &dirs, false)
+		resp.Diagnostics.Append(diags...)
+	}
+	checksum := ""
+	if !data.Archive.Null && data.Archive.Value != "" {
+		dirs = []string{data.Archive.Value}
+		checksum = data.Sha256.Value
+	}
 
 	if os.Getenv("ALZLIB_DIR") != "" {
-		dir = os.Getenv("ALZLIB_DIR")
+		dirs = filepath.SplitList(os.Getenv("ALZLIB_DIR"))
+		checksum = ""
+	} else if !data.Source.Null && data.Source.Value != "" {
+		dir, diags := fetchSource(ctx, data.Source.Value, data.CacheDir.Value, data.Sha256.Value)
+		resp.Diagnostics.Append(diags...)
+		dirs = []string{dir}
+		checksum = data.Sha256.Value
 	}
 
-	if dir == "" {
-		resp.Diagnostics.AddError(
-			"alzlib directory not set",
-			"Either set the `directory` property of the provider configuration, or set the `ALZLIB_DIR` environment variable.",
+	validationMode := validationModeError
+	if !data.ValidationMode.Null && data.ValidationMode.Value != "" {
+		validationMode = data.ValidationMode.Value
+	}
+	if validationMode != validationModeError && validationMode != validationModeWarning {
+		resp.Diagnostics.AddAttributeError(
+			tftypes.NewAttributePath().WithAttributeName("validation_mode"),
+			"invalid validation mode",
+			fmt.Sprintf("The validation mode %q is not valid, it must be `%s` or `%s`.", validationMode, validationModeError, validationModeWarning),
 		)
+	}
+
+	walk, diags := walkOptionsFromData(ctx, data)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	mode := resolveLibraryMode(data.LibraryMode.Value, dirs)
+	layers, diags := libraryLayers(mode, dirs, checksum)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
+	setWalkOptions(ctx, layers, walk)
+	useCache, diags := useParseCache(data.ParseCache, data.TrustedKeys, data.LockFile)
+	resp.Diagnostics.Append(diags...)
+	if useCache {
+		resp.Diagnostics.Append(setParseCache(layers, data.CacheDir.Value)...)
+	}
+
+	var manifests []*bundle.Manifest
+	if !data.TrustedKeys.Null {
+		manifests, diags = readLibraryManifests(ctx, layers, data.TrustedKeys)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	var catalog *alzlib.BuiltInCatalog
+	if !data.BuiltInPolicyCatalog.Null && data.BuiltInPolicyCatalog.Value != "" {
+		cat, err := alzlib.ReadBuiltInCatalog(data.BuiltInPolicyCatalog.Value)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(tftypes.NewAttributePath().WithAttributeName("built_in_policy_catalog"), "error reading built-in policy catalog", err.Error())
+			return
+		}
+		catalog = cat
+	}
 
-	c, err := alzlib.NewAlzLib(dir)
+	c, findings, err := alzlib.ValidateLayers(catalog, layers...)
 	if err != nil {
 		resp.Diagnostics.AddError("error configuring provider", err.Error())
+		return
+	}
+	if manifests != nil {
+		resp.Diagnostics.Append(verifyLibraryFiles(layers, manifests, c.Files())...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	resp.Diagnostics.Append(findingDiagnostics(validationMode, findings)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !data.LockFile.Null && data.LockFile.Value != "" {
+		resp.Diagnostics.Append(checkLockFile(data.LockFile.Value, c)...)
 	}
 
+	vars, diags := templateVariablesFromMap(ctx, data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
 	p.client = c
+	p.findings = findings
+	p.validationMode = validationMode
+	p.embeddedLibrary = mode != libraryModeDirectory
+	p.templateVariables = vars
 	p.configured = true
 }
 
@@ -78,18 +222,154 @@ func (p *provider) GetResources(ctx context.Context) (map[string]tfsdk.ResourceT
 
 func (p *provider) GetDataSources(ctx context.Context) (map[string]tfsdk.DataSourceType, diag.Diagnostics) {
 	return map[string]tfsdk.DataSourceType{
-		"alzlib_archetypes": archetypesDataSourceType{},
+		"alzlib_archetype":                  archetypeDataSourceType{},
+		"alzlib_archetypes":                 archetypesDataSourceType{},
+		"alzlib_hygiene":                    hygieneDataSourceType{},
+		"alzlib_library":                    libraryDataSourceType{},
+		"alzlib_management_group_hierarchy": managementGroupHierarchyDataSourceType{},
+		"alzlib_policy_assignment":          policyAssignmentDataSourceType{},
+		"alzlib_policy_definition":          policyDefinitionDataSourceType{},
+		"alzlib_policy_set_definition":      policySetDefinitionDataSourceType{},
+		"alzlib_role_definitions":           roleDefinitionsDataSourceType{},
+		"alzlib_validation":                 validationDataSourceType{},
 	}, nil
 }
 
 func (p *provider) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
 	return tfsdk.Schema{
 		Attributes: map[string]tfsdk.Attribute{
+			"archive": {
+				MarkdownDescription: "A `.zip` or `.tar.gz` archive containing ALZ lib files, which is read without being extracted. " +
+					fmt.Sprintf("A `.tar.gz` archive is rejected if it has more than %d entries or its files total more than %d MiB. ", alzlib.MaxArchiveEntries, alzlib.MaxArchiveUncompressedSize>>20) +
+					"Conflicts with `directory` and `directories`.",
+				Optional: true,
+				Type:     types.StringType,
+			},
+			"built_in_policy_catalog": {
+				MarkdownDescription: "The path of an offline catalog of the built-in policy definitions, " +
+					"a JSON array of policy definitions, e.g. the output of `az policy definition list --query \"[?policyType=='BuiltIn']\"`. " +
+					"If set, the built-in members of the policy set definitions are checked against it, and missing members are problems in the library. " +
+					"Otherwise only the members that are custom policy definitions are checked, against the library.",
+				Optional: true,
+				Type:     types.StringType,
+			},
+			"cache_dir": {
+				MarkdownDescription: "The directory that libraries fetched from a `source`, and parsed lib files, are cached in. " +
+					"Defaults to the `ALZLIB_CACHE_DIR` environment variable, or a directory in the user's cache directory.",
+				Optional: true,
+				Type:     types.StringType,
+			},
 			"directory": {
-				MarkdownDescription: "Directory containing ALZ lib files",
+				MarkdownDescription: "Directory containing ALZ lib files, or a `.zip` or `.tar.gz` archive of them",
 				Optional:            true, //can be set using ALZLIB_DIR env var
 				Type:                types.StringType,
 			},
+			"directories": {
+				MarkdownDescription: "Directories containing ALZ lib files, which are processed in order as layers. " +
+					"Objects in a later layer replace objects with the same name in an earlier layer, " +
+					"but declaring the same object twice in one layer is an error. " +
+					"Archetype extensions and exclusions from all layers are applied. " +
+					"Conflicts with `directory`. The `ALZLIB_DIR` environment variable can also contain a list of directories, " +
+					"separated by the OS path list separator.",
+				Optional: true, //can be set using ALZLIB_DIR env var
+				Type:     types.ListType{ElemType: types.StringType},
+			},
+			"exclude_patterns": {
+				MarkdownDescription: "Glob patterns of the files and directories in the library directories and archives that are not read, " +
+					"e.g. `drafts` or `**/*.tmp.json`. Patterns without a `/` match the file or directory name, " +
+					"otherwise they match the path within the directory, where `**` matches any number of directories. " +
+					"They take precedence over `include_patterns` and do not apply to the embedded library.",
+				Optional: true,
+				Type:     types.ListType{ElemType: types.StringType},
+			},
+			"follow_symlinks": {
+				MarkdownDescription: "Whether to read the files in symlinked directories in the library directories. " +
+					"Directories that have already been read, e.g. because of a symlink loop, are skipped. Defaults to `false`.",
+				Optional: true,
+				Type:     types.BoolType,
+			},
+			"include_hidden_directories": {
+				MarkdownDescription: "Whether to read the files in directories whose names start with a dot, e.g. `.git`. Defaults to `false`.",
+				Optional:            true,
+				Type:                types.BoolType,
+			},
+			"include_patterns": {
+				MarkdownDescription: "Glob patterns of the files in the library directories and archives that are read, " +
+					"using the same syntax as `exclude_patterns`, e.g. `platform/**/*.json`. " +
+					"If not set, all files are read. They do not apply to the embedded library.",
+				Optional: true,
+				Type:     types.ListType{ElemType: types.StringType},
+			},
+			"library_mode": {
+				MarkdownDescription: "Where the lib files are read from. " +
+					"`embedded` uses the reference library that is embedded in the provider, " +
+					"`directory` uses the configured `directory` or `directories`, and " +
+					"`layered` uses the embedded library as the first layer with the configured directories layered over it. " +
+					"Defaults to `directory` if a directory is configured, otherwise `embedded`.",
+				Optional: true,
+				Type:     types.StringType,
+			},
+			"lock_file": {
+				MarkdownDescription: "The path of a lock file, conventionally `" + lockfile.DefaultFileName + "` in the root module directory, " +
+					"that records the path, kind and SHA-256 of every lib file that is loaded. " +
+					"It is written if it does not exist. If it does, configuring the provider fails, listing the changed objects, " +
+					"if the library does not match it, unless the `" + lockUpdateEnvVar + "` environment variable is set to `true`, " +
+					"which updates the lock file.",
+				Optional: true,
+				Type:     types.StringType,
+			},
+			"parse_cache": {
+				MarkdownDescription: "Whether to cache the parsed lib files in the `" + parseCacheDir + "` directory of the `cache_dir`, " +
+					"so that the lib files whose size and modification time have not changed are not read and parsed again. " +
+					"Only the lib files in directories are cached, the embedded library and archives are always read. " +
+					"The cached objects are not checked against the lib files, so the cache is not used if `trusted_keys` or `lock_file` is set. Defaults to `false`.",
+				Optional: true,
+				Type:     types.BoolType,
+			},
+			"sha256": {
+				MarkdownDescription: "The hex encoded SHA-256 checksum of the `archive`, or of the archive `source`. " +
+					"If set, configuring the provider fails if the archive does not have this checksum. " +
+					"It is not used if the `ALZLIB_DIR` environment variable is set.",
+				Optional: true,
+				Type:     types.StringType,
+			},
+			"source": {
+				MarkdownDescription: "A remote address to fetch the ALZ lib files from, either a git repository, " +
+					"e.g. `git::https://example.com/lib.git//lib?ref=v2.1.0`, or the HTTPS URL of a `.zip` or `.tar.gz` archive. " +
+					"Git remotes can use `https://`, `ssh://`, e.g. `git::ssh://git@example.com/lib.git`, or `file://`, for mirrors. " +
+					"The sub directory after `//` and the `ref` are optional. " +
+					"The library is downloaded into the `cache_dir`, and the cached copy is used, with a warning, if it cannot be fetched. " +
+					"A `ref` that is a full commit id is only fetched once, other refs, e.g. branches and tags, are fetched every time the provider is configured. " +
+					"Git sources are only verified by pinning a commit id, which the fetched commit is checked against, `sha256` only applies to archives. " +
+					"Archives larger than 64 MiB are rejected, and downloads time out after 5 minutes. " +
+					"Conflicts with `archive`, `directory` and `directories`.",
+				Optional: true,
+				Type:     types.StringType,
+			},
+			"trusted_keys": {
+				MarkdownDescription: "Base64 encoded ed25519 public keys that library bundles must be signed with. " +
+					"If set, each library layer, apart from the embedded library, must contain an `" + bundle.ManifestFileName + "` manifest, " +
+					"signed by one of these keys, and configuring the provider fails if any lib file that is read is unsigned, or is changed or missing compared with the manifest. " +
+					"Bundles are created with the `pack` and `sign` commands of the provider binary.",
+				Optional: true,
+				Type:     types.ListType{ElemType: types.StringType},
+			},
+			"template_variables": {
+				MarkdownDescription: "Values used to render the `${...}` placeholders in the lib files, e.g. `root_scope_id` and `default_location`. " +
+					"If `root_scope_resource_id` or `current_scope_resource_id` are not supplied, they are derived from `root_scope_id` and `current_scope_id`. " +
+					"If not set, the lib file contents are returned unrendered.",
+				Optional: true,
+				Type:     types.MapType{ElemType: types.StringType},
+			},
+			"validation_mode": {
+				MarkdownDescription: "How problems in the library are reported. All of the problems are reported at once, " +
+					"each as a separate diagnostic with the lib file and the JSON pointer to the value that has the problem. " +
+					"`" + validationModeError + "` reports them as errors, so configuring the provider fails. " +
+					"`" + validationModeWarning + "` reports them as warnings and uses the library without the objects that have problems, " +
+					"the problems are also listed by the `alzlib_validation` data source. Defaults to `" + validationModeError + "`.",
+				Optional: true,
+				Type:     types.StringType,
+			},
 		},
 	}, nil
 }
@@ -130,3 +410,325 @@ func convertProviderType(in tfsdk.Provider) (provider, diag.Diagnostics) {
 
 	return *p, diags
 }
+
+// These are the values of the library_mode provider attribute
+const (
+	libraryModeDirectory = "directory"
+	libraryModeEmbedded  = "embedded"
+	libraryModeLayered   = "layered"
+)
+
+// resolveLibraryMode returns the library mode to use.
+// If the mode is not set, the directories are used if there are any, otherwise the embedded library is used.
+func resolveLibraryMode(mode string, dirs []string) string {
+	if mode != "" {
+		return mode
+	}
+	if len(dirs) > 0 {
+		return libraryModeDirectory
+	}
+	return libraryModeEmbedded
+}
+
+// libraryLayers returns the library layers for the supplied library mode and directories.
+// A directory can also be a lib archive, if the checksum is not empty it is verified for each archive.
+func libraryLayers(mode string, dirs []string, checksum string) ([]alzlib.LibLayer, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	layers := make([]alzlib.LibLayer, 0, len(dirs)+1)
+	switch mode {
+	case libraryModeEmbedded:
+		return append(layers, library.Layer()), diags
+	case libraryModeLayered:
+		layers = append(layers, library.Layer())
+	case libraryModeDirectory:
+		if len(dirs) == 0 {
+			diags.AddError(
+				"alzlib directory not set",
+				"Either set the `directory` or `directories` property of the provider configuration, or set the `ALZLIB_DIR` environment variable.",
+			)
+			return nil, diags
+		}
+	default:
+		diags.AddAttributeError(
+			tftypes.NewAttributePath().WithAttributeName("library_mode"),
+			"invalid library mode",
+			fmt.Sprintf("The library mode %q is not valid, it must be one of `%s`, `%s` or `%s`.", mode, libraryModeDirectory, libraryModeEmbedded, libraryModeLayered),
+		)
+		return nil, diags
+	}
+
+	for _, dir := range dirs {
+		var l alzlib.LibLayer
+		var err error
+		if alzlib.IsArchive(dir) {
+			l, err = alzlib.ArchiveLayer(dir, checksum)
+		} else {
+			l, err = alzlib.DirLayer(dir)
+		}
+		var mismatch *alzlib.ChecksumMismatchError
+		if errors.As(err, &mismatch) {
+			diags.AddAttributeError(
+				tftypes.NewAttributePath().WithAttributeName("sha256"),
+				"alzlib archive checksum mismatch",
+				err.Error(),
+			)
+			continue
+		}
+		if err != nil {
+			diags.AddError("error configuring provider", err.Error())
+			continue
+		}
+		layers = append(layers, l)
+	}
+	return layers, diags
+}
+
+// walkOptionsFromData returns the walk options of the library layers from the provider configuration,
+// the glob patterns are validated
+func walkOptionsFromData(ctx context.Context, data providerData) (alzlib.WalkOptions, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	opts := alzlib.WalkOptions{
+		FollowSymlinks: data.FollowSymlinks.Value,
+		IncludeHidden:  data.IncludeHidden.Value,
+	}
+	opts.Include = globPatternsFromList(ctx, "include_patterns", data.IncludePatterns, &diags)
+	opts.Exclude = globPatternsFromList(ctx, "exclude_patterns", data.ExcludePatterns, &diags)
+	return opts, diags
+}
+
+// globPatternsFromList returns the glob patterns in the list attribute with the supplied name, adding an error for each invalid pattern
+func globPatternsFromList(ctx context.Context, name string, l types.List, diags *diag.Diagnostics) []string {
+	if l.Null || l.Unknown {
+		return nil
+	}
+	var patterns []string
+	diags.Append(l.ElementsAs(ctx, &patterns, false)...)
+	for i, pattern := range patterns {
+		if err := alzlib.ValidateGlob(pattern); err != nil {
+			diags.AddAttributeError(tftypes.NewAttributePath().WithAttributeName(name).WithElementKeyInt(i), "invalid glob pattern", err.Error())
+		}
+	}
+	return patterns
+}
+
+// setWalkOptions sets the walk options of the library layers, the embedded library is always read in full.
+// Every file that is processed or skipped is logged at debug level.
+func setWalkOptions(ctx context.Context, layers []alzlib.LibLayer, opts alzlib.WalkOptions) {
+	for i := range layers {
+		name := layers[i].Name
+		if name != library.LayerName {
+			layers[i].Walk = opts
+		}
+		layers[i].Walk.Log = func(p, classification string) {
+			tflog.Debug(ctx, "alzlib library file", map[string]interface{}{
+				"layer":          name,
+				"path":           p,
+				"classification": classification,
+			})
+		}
+	}
+}
+
+// parseCacheDir is the directory of the parse cache in the cache directory
+const parseCacheDir = "parsed"
+
+// useParseCache returns whether the parse cache is used, which is only if it is enabled.
+// It is not used if the library is verified with trusted keys or a lock file,
+// as the cached objects and SHA-256s are not checked against the lib file bytes.
+func useParseCache(parseCache types.Bool, trustedKeys types.List, lockFile types.String) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if parseCache.Null || !parseCache.Value {
+		return false, diags
+	}
+	var verifiedBy string
+	switch {
+	case !trustedKeys.Null:
+		verifiedBy = "trusted_keys"
+	case !lockFile.Null && lockFile.Value != "":
+		verifiedBy = "lock_file"
+	default:
+		return true, diags
+	}
+	diags.AddAttributeWarning(
+		tftypes.NewAttributePath().WithAttributeName("parse_cache"),
+		"alzlib parse cache disabled",
+		fmt.Sprintf("The parse cache is not used when `%s` is set, the lib files are read and verified every time.", verifiedBy),
+	)
+	return false, diags
+}
+
+// setParseCache sets the parse cache of the library layers, using the supplied cache directory or the default.
+// The library can be loaded without the cache, so problems creating it are warnings.
+func setParseCache(layers []alzlib.LibLayer, cacheDir string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if cacheDir == "" {
+		dir, err := remote.DefaultCacheDir()
+		if err != nil {
+			diags.AddAttributeWarning(tftypes.NewAttributePath().WithAttributeName("cache_dir"), "alzlib parse cache disabled", err.Error())
+			return diags
+		}
+		cacheDir = dir
+	}
+	cache, err := alzlib.NewParseCache(filepath.Join(cacheDir, parseCacheDir))
+	if err != nil {
+		diags.AddAttributeWarning(tftypes.NewAttributePath().WithAttributeName("cache_dir"), "alzlib parse cache disabled", err.Error())
+		return diags
+	}
+	for i := range layers {
+		layers[i].Cache = cache
+	}
+	return diags
+}
+
+// fetchSource fetches the library from the source address into the cache directory and returns its local path.
+// If the cache directory is empty the default is used.
+func fetchSource(ctx context.Context, addr, cacheDir, checksum string) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	sourcePath := tftypes.NewAttributePath().WithAttributeName("source")
+
+	if cacheDir == "" {
+		dir, err := remote.DefaultCacheDir()
+		if err != nil {
+			diags.AddAttributeError(tftypes.NewAttributePath().WithAttributeName("cache_dir"), "error determining alzlib cache directory", err.Error())
+			return "", diags
+		}
+		cacheDir = dir
+	}
+
+	f := &remote.Fetcher{CacheDir: cacheDir}
+	res, err := f.Fetch(ctx, addr, checksum)
+	var mismatch *alzlib.ChecksumMismatchError
+	if errors.As(err, &mismatch) {
+		diags.AddAttributeError(tftypes.NewAttributePath().WithAttributeName("sha256"), "alzlib archive checksum mismatch", err.Error())
+		return "", diags
+	}
+	if err != nil {
+		diags.AddAttributeError(sourcePath, "error fetching alzlib source", err.Error())
+		return "", diags
+	}
+	if res.FetchError != nil {
+		diags.AddAttributeWarning(
+			sourcePath,
+			"using cached alzlib source",
+			fmt.Sprintf("The source %s could not be fetched, so the copy in the cache %s is used. %s", addr, cacheDir, res.FetchError),
+		)
+	}
+	return res.Path, diags
+}
+
+// readLibraryManifests reads the manifest of each layer, apart from the embedded library, and checks that it is signed by one of the trusted keys.
+// The manifests are indexed by layer, the embedded library has no manifest.
+func readLibraryManifests(ctx context.Context, layers []alzlib.LibLayer, trustedKeys types.List) ([]*bundle.Manifest, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	keysPath := tftypes.NewAttributePath().WithAttributeName("trusted_keys")
+
+	var encoded []string
+	diags.Append(trustedKeys.ElementsAs(ctx, &encoded, false)...)
+	keys := make([]ed25519.PublicKey, 0, len(encoded))
+	for i, e := range encoded {
+		k, err := bundle.ParsePublicKey(e)
+		if err != nil {
+			diags.AddAttributeError(keysPath.WithElementKeyInt(i), "invalid trusted key", err.Error())
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	manifests := make([]*bundle.Manifest, len(layers))
+	for i, l := range layers {
+		if l.Name == library.LayerName {
+			continue
+		}
+		m, err := bundle.ReadManifest(l.FS, keys)
+		if err != nil {
+			diags.AddError("untrusted alzlib library", fmt.Sprintf("The library %s is not trusted: %s", l.Name, err))
+			continue
+		}
+		manifests[i] = m
+	}
+	return manifests, diags
+}
+
+// verifyLibraryFiles checks that the lib files that were loaded from each layer with a manifest are exactly the lib files in the manifest.
+// The hashes of the loaded files are those of the data that was parsed, so a file cannot be changed after it has been checked.
+func verifyLibraryFiles(layers []alzlib.LibLayer, manifests []*bundle.Manifest, files []alzlib.LibFile) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for i, m := range manifests {
+		if m == nil {
+			continue
+		}
+		hashes := make(map[string]string)
+		for _, f := range files {
+			if f.Layer == i {
+				hashes[f.Path] = f.Sha256
+			}
+		}
+		if err := m.Verify(hashes, alzlib.IsLibFile); err != nil {
+			diags.AddError("untrusted alzlib library", fmt.Sprintf("The library %s is not trusted: %s", layers[i].Name, err))
+		}
+	}
+	return diags
+}
+
+// lockUpdateEnvVar is the environment variable that allows the lock file to be updated
+const lockUpdateEnvVar = "ALZLIB_UPDATE_LOCK"
+
+// checkLockFile compares the library with the lock file at the supplied path.
+// The lock file is written if it does not exist, or if it differs and the update environment variable is set.
+func checkLockFile(p string, az *alzlib.AlzLib) diag.Diagnostics {
+	var diags diag.Diagnostics
+	lockPath := tftypes.NewAttributePath().WithAttributeName("lock_file")
+
+	current := lockfile.FromLibrary(az)
+	locked, err := lockfile.Read(p)
+	if err != nil {
+		diags.AddAttributeError(lockPath, "error reading alzlib lock file", err.Error())
+		return diags
+	}
+
+	if locked != nil {
+		diff := locked.Diff(current)
+		if diff == nil {
+			return diags
+		}
+		if update, _ := strconv.ParseBool(os.Getenv(lockUpdateEnvVar)); !update {
+			diags.AddAttributeError(
+				lockPath,
+				"alzlib library does not match the lock file",
+				fmt.Sprintf("The library has changed since the lock file %s was written:\n\n%s\n\n"+
+					"If these changes are expected, set the %s environment variable to true to update the lock file.",
+					p, strings.Join(diff, "\n"), lockUpdateEnvVar),
+			)
+			return diags
+		}
+	}
+
+	if err := current.Write(p); err != nil {
+		diags.AddAttributeError(lockPath, "error writing alzlib lock file", err.Error())
+	}
+	return diags
+}
+
+// These are the values of the validation_mode provider attribute
+const (
+	validationModeError   = "error"
+	validationModeWarning = "warning"
+)
+
+// findingDiagnostics returns a diagnostic for each of the library findings,
+// they are errors or warnings depending on the validation mode, findings that are warnings are always warnings
+func findingDiagnostics(validationMode string, findings []alzlib.Finding) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, f := range findings {
+		if f.Warning || validationMode == validationModeWarning {
+			diags.AddWarning("alzlib library problem", f.Error())
+			continue
+		}
+		diags.AddError("alzlib library problem", f.Error())
+	}
+	return diags
+}
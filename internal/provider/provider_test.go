@@ -16,6 +16,9 @@ var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServe
 	"alzlib": providerserver.NewProtocol6WithError(New("test")()),
 }
 
+// testLibDir is the directory of the reference library that is embedded in the provider, for the tests that need a lib directory
+const testLibDir = "../library/lib"
+
 func testAccPreCheck(t *testing.T) {
 	// You can add code here to run prior to any test case execution, for example assertions
 	// about the appropriate environment variables being set are common to see in a pre-check
@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = roleDefinitionsDataSourceType{}
+var _ tfsdk.DataSource = roleDefinitionsDataSource{}
+
+type roleDefinitionsDataSourceType struct{}
+
+func (t roleDefinitionsDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Custom role definitions from the library, keyed by role name",
+
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "A fingerprint of the `role_definitions`, which changes when their content changes",
+				Type:                types.StringType,
+				Computed:            true,
+			},
+			"template_variables": {
+				MarkdownDescription: "Values used to render the `${...}` placeholders in the lib files for this data source, " +
+					"e.g. `current_scope_id`. These are merged over the provider `template_variables`.",
+				Optional: true,
+				Type:     types.MapType{ElemType: types.StringType},
+			},
+			"role_definitions": {
+				Computed: true,
+				Type:     roleDefinitionType(),
+			},
+		},
+	}, nil
+}
+
+func roleDefinitionType() types.MapType {
+	stringList := types.ListType{ElemType: types.StringType}
+	return types.MapType{
+		ElemType: types.ObjectType{
+			AttrTypes: map[string]attr.Type{
+				"name":         types.StringType,
+				"layer":        types.StringType,
+				"content_hash": types.StringType,
+				"role_name":    types.StringType,
+				"description":  types.StringType,
+				"role_type":    types.StringType,
+				"permissions": types.ListType{
+					ElemType: types.ObjectType{
+						AttrTypes: map[string]attr.Type{
+							"actions":          stringList,
+							"not_actions":      stringList,
+							"data_actions":     stringList,
+							"not_data_actions": stringList,
+						},
+					},
+				},
+				"assignable_scopes": stringList,
+			},
+		},
+	}
+}
+
+func (t roleDefinitionsDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return roleDefinitionsDataSource{
+		provider: provider,
+	}, diags
+}
+
+type roleDefinitionsDataSource struct {
+	provider provider
+}
+
+type roleDefinitionsDataSourceData struct {
+	Id                types.String                  `tfsdk:"id"`
+	TemplateVariables types.Map                     `tfsdk:"template_variables"`
+	RoleDefinitions   map[string]roleDefinitionData `tfsdk:"role_definitions"`
+}
+
+func (d roleDefinitionsDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	data := roleDefinitionsDataSourceData{
+		RoleDefinitions: make(map[string]roleDefinitionData),
+	}
+
+	diags := req.Config.GetAttribute(ctx, tftypes.NewAttributePath().WithAttributeName("template_variables"), &data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+	dsVars, diags := templateVariablesFromMap(ctx, data.TemplateVariables)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	vars := mergeTemplateVariables(d.provider.templateVariables, dsVars)
+
+	for rdk, rdv := range d.provider.client.RoleDefinitions {
+		rd := *rdv
+		if vars != nil {
+			rendered, err := d.provider.client.RenderRoleDefinition(rd, vars)
+			if err != nil {
+				addRenderErrorDiagnostic(&resp.Diagnostics, err)
+				continue
+			}
+			rd = *rendered
+		}
+		rdd, err := newRoleDefinitionData(rd)
+		if err != nil {
+			resp.Diagnostics.AddError("Error reading role definitions", fmt.Sprintf("Unable to read role definition %s: %s", rdk, err))
+			continue
+		}
+		rdd.Layer = layerValue(d.provider.client, alzlib.KindRoleDefinition, rdk)
+		data.RoleDefinitions[rdk] = rdd
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, err := contentHash(contentHashes(data.RoleDefinitions, func(v roleDefinitionData) types.String { return v.ContentHash }))
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading role definitions", err.Error())
+		return
+	}
+	data.Id = id
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// newRoleDefinitionData converts the supplied role definition into the data source model
+func newRoleDefinitionData(rd alzlib.RoleDefinition) (roleDefinitionData, error) {
+	props := rd.Properties
+	if props == nil {
+		props = &alzlib.RoleDefinitionProperties{}
+	}
+
+	rdd := roleDefinitionData{
+		Name:             stringValue(rd.Name),
+		RoleName:         stringValue(props.RoleName),
+		Description:      stringValue(props.Description),
+		RoleType:         stringValue(props.RoleType),
+		Permissions:      make([]roleDefinitionPermissionData, 0, len(props.Permissions)),
+		AssignableScopes: stringListValue(props.AssignableScopes),
+	}
+
+	for _, p := range props.Permissions {
+		if p == nil {
+			continue
+		}
+		rdd.Permissions = append(rdd.Permissions, roleDefinitionPermissionData{
+			Actions:        stringListValue(p.Actions),
+			NotActions:     stringListValue(p.NotActions),
+			DataActions:    stringListValue(p.DataActions),
+			NotDataActions: stringListValue(p.NotDataActions),
+		})
+	}
+
+	hash, err := contentHash(rd)
+	if err != nil {
+		return rdd, err
+	}
+	rdd.ContentHash = hash
+
+	return rdd, nil
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRoleDefinitionsDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccRoleDefinitionsDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.alzlib_role_definitions.test", "role_definitions.%", "5"),
+					resource.TestCheckResourceAttr("data.alzlib_role_definitions.test", "role_definitions.Application-Owners.name", "c9a07a05-a1fc-53fe-a565-5eed25597c03"),
+					resource.TestCheckResourceAttr("data.alzlib_role_definitions.test", "role_definitions.Application-Owners.permissions.0.not_actions.#", "4"),
+					resource.TestCheckResourceAttr("data.alzlib_role_definitions.test", "role_definitions.Application-Owners.assignable_scopes.0", "/providers/Microsoft.Management/managementGroups/alz"),
+				),
+			},
+		},
+	})
+}
+
+const testAccRoleDefinitionsDataSourceConfig = `
+data "alzlib_role_definitions" "test" {
+  template_variables = {
+    current_scope_id = "alz"
+  }
+}
+`
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// templateVariablesFromMap converts the supplied Terraform map into template variables.
+// A null map returns nil, which means that the lib file contents are not rendered.
+func templateVariablesFromMap(ctx context.Context, m types.Map) (alzlib.TemplateVariables, diag.Diagnostics) {
+	if m.Null || m.Unknown {
+		return nil, nil
+	}
+	vars := make(map[string]string, len(m.Elems))
+	diags := m.ElementsAs(ctx, &vars, false)
+	return vars, diags
+}
+
+// mergeTemplateVariables merges the data source template variables over those of the provider.
+// If neither are set then nil is returned and the lib file contents are not rendered.
+func mergeTemplateVariables(providerVars, dataSourceVars alzlib.TemplateVariables) alzlib.TemplateVariables {
+	if providerVars == nil && dataSourceVars == nil {
+		return nil
+	}
+	return providerVars.Merge(dataSourceVars)
+}
+
+// addRenderErrorDiagnostic adds a diagnostic for an error returned while rendering a lib file.
+// Unknown template variables produce a diagnostic that names the file and the variables.
+func addRenderErrorDiagnostic(diags *diag.Diagnostics, err error) {
+	var te *alzlib.TemplateError
+	if errors.As(err, &te) {
+		diags.AddError(
+			"Unknown template variable",
+			fmt.Sprintf("The %s %s, in file %s, references template variable(s) that have not been supplied: %v. "+
+				"Set them using the `template_variables` attribute.", te.Kind, te.Name, te.File, te.Variables),
+		)
+		return
+	}
+	diags.AddError("Error rendering lib file", err.Error())
+}
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// contentHash returns the fingerprint of the supplied value, which is used for the content_hash attributes
+func contentHash(v interface{}) (types.String, error) {
+	hash, err := alzlib.Fingerprint(v)
+	if err != nil {
+		return types.String{Null: true}, err
+	}
+	return types.String{Value: hash}, nil
+}
+
+// contentHashes returns the content hashes of the objects in the supplied map, keyed by the same name
+func contentHashes[T any](m map[string]T, hash func(T) types.String) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = hash(v).Value
+	}
+	return result
+}
+
+// stringValue returns a types.String from the supplied string pointer,
+// which is null if the pointer is nil
+func stringValue(s *string) types.String {
+	if s == nil {
+		return types.String{Null: true}
+	}
+	return types.String{Value: *s}
+}
+
+// jsonStringValue returns a types.String holding the compact JSON representation of the supplied value,
+// which is null if the value is nil or empty.
+// If the value cannot be represented as JSON, a diagnostic is added for the attribute at the supplied path.
+func jsonStringValue(v interface{}, path *tftypes.AttributePath, diags *diag.Diagnostics) types.String {
+	s, err := flattenValueToString(v)
+	if err != nil {
+		diags.AddAttributeError(path, "Invalid JSON value", fmt.Sprintf("Unable to convert the value to JSON: %s", err))
+		return types.String{Null: true}
+	}
+	if s == "" {
+		return types.String{Null: true}
+	}
+	return types.String{Value: s}
+}
+
+// metadataStringValue returns the named string value in the supplied metadata, which is null if it is not set.
+// If the value is not a string, a diagnostic is added for the attribute at the supplied path.
+func metadataStringValue(metadata map[string]interface{}, key string, path *tftypes.AttributePath, diags *diag.Diagnostics) types.String {
+	v, ok := metadata[key]
+	if !ok || v == nil {
+		return types.String{Null: true}
+	}
+	s, ok := v.(string)
+	if !ok {
+		diags.AddAttributeError(path, "Invalid metadata value", fmt.Sprintf("The metadata %s must be a string, got %T", key, v))
+		return types.String{Null: true}
+	}
+	return types.String{Value: s}
+}
+
+// stringListValue returns a types.List of strings from the supplied slice of string pointers.
+// Nil pointers in the slice are skipped.
+func stringListValue(s []*string) types.List {
+	elems := make([]attr.Value, 0, len(s))
+	for _, v := range s {
+		if v == nil {
+			continue
+		}
+		elems = append(elems, types.String{Value: *v})
+	}
+	return types.List{ElemType: types.StringType, Elems: elems}
+}
+
+// boolValueOrDefault returns the value of the supplied types.Bool, or the default if it is null or unknown
+func boolValueOrDefault(b types.Bool, def bool) bool {
+	if b.Null || b.Unknown {
+		return def
+	}
+	return b.Value
+}
+
+// sortedNamesValue returns a types.List of the keys of the supplied map, in sorted order
+func sortedNamesValue[T any](m map[string]T) types.List {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	elems := make([]attr.Value, len(names))
+	for i, n := range names {
+		elems[i] = types.String{Value: n}
+	}
+	return types.List{ElemType: types.StringType, Elems: elems}
+}
+
+// stringSetElements returns the strings in the supplied types.Set,
+// a null or unknown set returns nil
+func stringSetElements(ctx context.Context, s types.Set) ([]string, diag.Diagnostics) {
+	if s.Null || s.Unknown {
+		return nil, nil
+	}
+	result := make([]string, 0, len(s.Elems))
+	diags := s.ElementsAs(ctx, &result, false)
+	return result, diags
+}
+
+// layerValue returns the library layer directory that the named object was read from,
+// which is null if the object was not read from a lib file
+func layerValue(az *alzlib.AlzLib, kind alzlib.ObjectKind, name string) types.String {
+	src, ok := az.Source(kind, name)
+	if !ok {
+		return types.String{Null: true}
+	}
+	return types.String{Value: src.LayerPath}
+}
+
+// setResolutionStatus sets the resolution status of the members of the named policy set definition,
+// which is null if the policy set definition is not in the library
+func setResolutionStatus(az *alzlib.AlzLib, name string, psdd *policySetDefinitionData) {
+	members := az.PolicySetDefinitionMembers(name)
+	for i := range psdd.PolicyDefinitions {
+		if i < len(members) {
+			psdd.PolicyDefinitions[i].ResolutionStatus = types.String{Value: string(members[i].Status)}
+		}
+	}
+}
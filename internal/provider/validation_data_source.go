@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ tfsdk.DataSourceType = validationDataSourceType{}
+var _ tfsdk.DataSource = validationDataSource{}
+
+type validationDataSourceType struct{}
+
+func (t validationDataSourceType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "The problems that were found in the library when the provider was configured. " +
+			"Errors are only listed if the provider `validation_mode` is `" + validationModeWarning + "`, " +
+			"otherwise configuring the provider fails if there are any. Warnings, e.g. deprecated policy set definition members, are always listed.",
+
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {
+				MarkdownDescription: "A fingerprint of the `findings`, which changes when the problems change",
+				Type:                types.StringType,
+				Computed:            true,
+			},
+			"validation_mode": {
+				MarkdownDescription: "The `validation_mode` of the provider",
+				Computed:            true,
+				Type:                types.StringType,
+			},
+			"valid": {
+				MarkdownDescription: "Whether the library has no problems that are errors",
+				Computed:            true,
+				Type:                types.BoolType,
+			},
+			"findings": {
+				MarkdownDescription: "The problems in the library, in the order that they were found. " +
+					"The `file` is the path of the lib file, which is empty if the problem is not in a lib file, " +
+					"the `pointer` is the JSON pointer to the value in the file, which is empty if the problem is with the whole file, " +
+					"the `message` describes the problem and the `severity` is `error` or `warning`.",
+				Computed: true,
+				Type:     types.ListType{ElemType: findingType()},
+			},
+		},
+	}, nil
+}
+
+func findingType() types.ObjectType {
+	return types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"file":     types.StringType,
+			"pointer":  types.StringType,
+			"message":  types.StringType,
+			"severity": types.StringType,
+		},
+	}
+}
+
+func (t validationDataSourceType) NewDataSource(ctx context.Context, in tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return validationDataSource{
+		provider: provider,
+	}, diags
+}
+
+type validationDataSource struct {
+	provider provider
+}
+
+type validationDataSourceData struct {
+	Id             types.String  `tfsdk:"id"`
+	ValidationMode types.String  `tfsdk:"validation_mode"`
+	Valid          types.Bool    `tfsdk:"valid"`
+	Findings       []findingData `tfsdk:"findings"`
+}
+
+type findingData struct {
+	File     types.String `tfsdk:"file"`
+	Pointer  types.String `tfsdk:"pointer"`
+	Message  types.String `tfsdk:"message"`
+	Severity types.String `tfsdk:"severity"`
+}
+
+func (d validationDataSource) Read(ctx context.Context, req tfsdk.ReadDataSourceRequest, resp *tfsdk.ReadDataSourceResponse) {
+	data := validationDataSourceData{
+		ValidationMode: types.String{Value: d.provider.validationMode},
+		Valid:          types.Bool{Value: true},
+		Findings:       make([]findingData, len(d.provider.findings)),
+	}
+	for i, f := range d.provider.findings {
+		severity := "error"
+		if f.Warning {
+			severity = "warning"
+		} else {
+			data.Valid = types.Bool{Value: false}
+		}
+		data.Findings[i] = findingData{
+			File:     types.String{Value: f.File},
+			Pointer:  types.String{Value: f.Pointer},
+			Message:  types.String{Value: f.Message},
+			Severity: types.String{Value: severity},
+		}
+	}
+
+	fingerprint, err := alzlib.Fingerprint(d.provider.findings)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading library validation", err.Error())
+		return
+	}
+	data.Id = types.String{Value: fingerprint}
+
+	diags := resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
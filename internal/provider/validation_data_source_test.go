@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+func TestAccValidationDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccValidationDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.alzlib_validation.test", "validation_mode", validationModeError),
+					resource.TestCheckResourceAttr("data.alzlib_validation.test", "valid", "true"),
+					resource.TestCheckResourceAttr("data.alzlib_validation.test", "findings.#", "0"),
+					resource.TestMatchResourceAttr("data.alzlib_validation.test", "id", sha256Regex),
+				),
+			},
+		},
+	})
+}
+
+const testAccValidationDataSourceConfig = `
+data "alzlib_validation" "test" {}
+`
+
+func TestFindingDiagnostics(t *testing.T) {
+	l, err := alzlib.DirLayer("../alzlib/testdata/badlib-validation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, findings, err := alzlib.ValidateLayers(nil, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) < 2 {
+		t.Fatalf("expected several findings, got %d", len(findings))
+	}
+
+	for _, c := range []struct {
+		mode     string
+		severity diag.Severity
+	}{
+		{mode: validationModeError, severity: diag.SeverityError},
+		{mode: validationModeWarning, severity: diag.SeverityWarning},
+	} {
+		diags := findingDiagnostics(c.mode, findings)
+		if len(diags) != len(findings) {
+			t.Fatalf("expected a diagnostic for each of the %d findings, got %v", len(findings), diags)
+		}
+		for _, d := range diags {
+			if d.Severity() != c.severity {
+				t.Errorf("expected %s diagnostics in %s mode, got %v", c.severity, c.mode, d)
+			}
+		}
+	}
+	diags := findingDiagnostics(validationModeError, findings)
+	if diags[0].Detail() != findings[0].Error() {
+		t.Errorf("expected the diagnostic detail to be %q, got %q", findings[0].Error(), diags[0].Detail())
+	}
+
+	diags = findingDiagnostics(validationModeError, []alzlib.Finding{{Message: "deprecated", Warning: true}})
+	if len(diags) != 1 || diags[0].Severity() != diag.SeverityWarning {
+		t.Errorf("expected a finding that is a warning to be a warning in %s mode, got %v", validationModeError, diags)
+	}
+}
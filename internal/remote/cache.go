@@ -0,0 +1,161 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// These are the names of the files and directories in the cache directory
+const cacheIndexFile = "index.json"
+const cacheObjectsDir = "objects"
+
+// cache is the local content-addressed cache of fetched libraries.
+// Objects are immutable once they are written, and are written by renaming a temporary file or directory,
+// so that an interrupted fetch does not leave a partial object in the cache.
+type cache struct {
+	dir string
+}
+
+// init creates the cache directory
+func (c *cache) init() error {
+	if c.dir == "" {
+		return fmt.Errorf("the cache directory is not set")
+	}
+	if err := os.MkdirAll(filepath.Join(c.dir, cacheObjectsDir), 0o755); err != nil {
+		return fmt.Errorf("error creating cache directory %s: %s", c.dir, err)
+	}
+	return nil
+}
+
+// objectPath returns the path of the object with the supplied key
+func (c *cache) objectPath(key string) string {
+	return filepath.Join(c.dir, cacheObjectsDir, key)
+}
+
+// path returns the path of the sub directory of the object with the supplied key
+func (c *cache) path(key, subdir string) string {
+	return filepath.Join(c.objectPath(key), filepath.FromSlash(subdir))
+}
+
+// exists returns true if the object with the supplied key is in the cache
+func (c *cache) exists(key string) bool {
+	_, err := os.Stat(c.objectPath(key))
+	return err == nil
+}
+
+// tempDir creates a temporary directory in the cache directory,
+// so that it can be renamed into the objects directory
+func (c *cache) tempDir() (string, error) {
+	return os.MkdirTemp(c.dir, "tmp-")
+}
+
+// tempFile creates a temporary file in the cache directory,
+// so that it can be renamed into the objects directory
+func (c *cache) tempFile() (*os.File, error) {
+	return os.CreateTemp(c.dir, "tmp-")
+}
+
+// storeFile moves the supplied file into the cache as the object with the supplied key, unless it already exists
+func (c *cache) storeFile(key, file string) error {
+	if c.exists(key) {
+		return nil
+	}
+	if err := os.Rename(file, c.objectPath(key)); err != nil && !c.exists(key) {
+		return fmt.Errorf("error writing to cache: %s", err)
+	}
+	return nil
+}
+
+// sha256 returns the hex encoded SHA-256 of the file object with the supplied key
+func (c *cache) sha256(key string) (string, error) {
+	f, err := os.Open(c.objectPath(key))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// storeDir moves the supplied directory into the cache as the object with the supplied key, unless it already exists
+func (c *cache) storeDir(key, dir string) error {
+	if c.exists(key) {
+		return nil
+	}
+	if err := os.Rename(dir, c.objectPath(key)); err != nil && !c.exists(key) {
+		return fmt.Errorf("error writing to cache: %s", err)
+	}
+	return nil
+}
+
+// readIndex reads the index, which maps source addresses to object keys
+func (c *cache) readIndex() (map[string]string, error) {
+	index := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(c.dir, cacheIndexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache index: %s", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("error reading cache index %s: %s", filepath.Join(c.dir, cacheIndexFile), err)
+	}
+	return index, nil
+}
+
+// lookupIndex returns the key of the object that the source address was last fetched as,
+// and whether it is in the cache
+func (c *cache) lookupIndex(addr string) (string, bool, error) {
+	index, err := c.readIndex()
+	if err != nil {
+		return "", false, err
+	}
+	key, ok := index[addr]
+	if !ok || !c.exists(key) {
+		return "", false, nil
+	}
+	return key, true, nil
+}
+
+// setIndex records that the source address was fetched as the object with the supplied key
+func (c *cache) setIndex(addr, key string) error {
+	index, err := c.readIndex()
+	if err != nil {
+		return err
+	}
+	if index[addr] == key {
+		return nil
+	}
+	index[addr] = key
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.dir, "tmp-")
+	if err != nil {
+		return fmt.Errorf("error writing cache index: %s", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("error writing cache index: %s", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing cache index: %s", err)
+	}
+	if err := os.Rename(f.Name(), filepath.Join(c.dir, cacheIndexFile)); err != nil {
+		return fmt.Errorf("error writing cache index: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// HTTPClient is the interface of the client used for HTTPS downloads, it is satisfied by *http.Client
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DownloadTimeout is the time limit of an HTTPS download, including reading the archive, if the Fetcher has no Client
+const DownloadTimeout = 5 * time.Minute
+
+// DefaultMaxArchiveSize is the maximum size, in bytes, of a downloaded archive if the Fetcher does not set one.
+// Library archives are a few megabytes, so a larger download is an error rather than filling the disk.
+const DefaultMaxArchiveSize = 64 << 20
+
+// defaultHTTPClient is used for HTTPS downloads if the Fetcher has no Client
+var defaultHTTPClient = &http.Client{Timeout: DownloadTimeout}
+
+// commitRegex matches a full git commit id
+var commitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// fetchGit fetches the git repository at the source ref into the cache and returns its key.
+// The working tree is stored without the .git directory, keyed by its commit id.
+// If the ref is a commit id, an error is returned if a different commit is fetched.
+func (f *Fetcher) fetchGit(ctx context.Context, c *cache, s source) (string, error) {
+	// a commit id always refers to the same content, so there is no need to fetch it if it is cached
+	if commitRegex.MatchString(s.ref) && c.exists("git-"+s.ref) {
+		return "git-" + s.ref, nil
+	}
+
+	tmp, err := c.tempDir()
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ref := s.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := runGit(ctx, tmp, "init", "--quiet"); err != nil {
+		return "", err
+	}
+	// the url and ref are separated from the options, so that they are never parsed as options
+	if _, err := runGit(ctx, tmp, "fetch", "--quiet", "--depth", "1", "--", s.url, ref); err != nil {
+		return "", err
+	}
+	if _, err := runGit(ctx, tmp, "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	commit, err := runGit(ctx, tmp, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if commitRegex.MatchString(s.ref) && commit != s.ref {
+		return "", fmt.Errorf("fetched commit %s from %s, expected %s", commit, s.url, s.ref)
+	}
+	if err := os.RemoveAll(filepath.Join(tmp, ".git")); err != nil {
+		return "", fmt.Errorf("error removing .git directory: %s", err)
+	}
+
+	key := "git-" + commit
+	if err := c.storeDir(key, tmp); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// runGit runs the git command in the supplied directory and returns its trimmed output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// never prompt for credentials, as there is no terminal
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running git %s: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// fetchArchive downloads the archive at the source URL into the cache and returns its key.
+// The archive is streamed to a temporary file while it is hashed, and is keyed by its SHA-256,
+// which is verified if checksum is not empty.
+func (f *Fetcher) fetchArchive(ctx context.Context, c *cache, s source, checksum string) (string, error) {
+	client := f.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	maxSize := f.MaxArchiveSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxArchiveSize
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error downloading %s: %s", s.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error downloading %s: %s", s.url, resp.Status)
+	}
+	if resp.ContentLength > maxSize {
+		return "", fmt.Errorf("error downloading %s: the archive is larger than the maximum size of %d bytes", s.url, maxSize)
+	}
+
+	tmp, err := c.tempFile()
+	if err != nil {
+		return "", fmt.Errorf("error writing to cache: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	// read one byte more than the maximum, so that a larger archive can be detected
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(resp.Body, maxSize+1))
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		return "", fmt.Errorf("error writing to cache: %s", closeErr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error downloading %s: %s", s.url, err)
+	}
+	if n > maxSize {
+		return "", fmt.Errorf("error downloading %s: the archive is larger than the maximum size of %d bytes", s.url, maxSize)
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if err := alzlib.VerifySha256(actual, checksum); err != nil {
+		return "", fmt.Errorf("archive %s: %w", s.url, err)
+	}
+	key := "sha256-" + actual + s.ext
+	if err := c.storeFile(key, tmp.Name()); err != nil {
+		return "", err
+	}
+	return key, nil
+}
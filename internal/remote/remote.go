@@ -0,0 +1,217 @@
+// Package remote fetches libraries from remote source addresses into a local content-addressed cache.
+//
+// The supported source addresses are git repositories, e.g. `git::https://example.com/lib.git//lib?ref=v2.1.0`,
+// which also supports `ssh://` and `file://` remotes, and HTTPS URLs of `.zip` or `.tar.gz` archives.
+//
+// A git source whose ref is a full commit id is only fetched once, and the fetched commit is checked against it.
+// Other refs, e.g. branches and tags, can move, so they are fetched every time, and the cached content is only used
+// if the fetch fails. Pinning a commit id is the only way to verify the content of a git source, checksums only apply
+// to archives.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+)
+
+// CacheDirEnvVar is the environment variable that overrides the default cache directory
+const CacheDirEnvVar = "ALZLIB_CACHE_DIR"
+
+// gitPrefix is the prefix of git source addresses
+const gitPrefix = "git::"
+
+// Fetcher downloads libraries into its cache directory.
+// The cache has an index, mapping each source address to the content that it was last fetched as,
+// and the content, which is stored by commit for git and by SHA-256 for archives.
+type Fetcher struct {
+	// CacheDir is the directory containing the cache
+	CacheDir string
+	// Client is used for HTTPS downloads, if nil a client with a timeout of DownloadTimeout is used
+	Client HTTPClient
+	// MaxArchiveSize is the maximum size, in bytes, of a downloaded archive, if zero DefaultMaxArchiveSize is used
+	MaxArchiveSize int64
+}
+
+// Result is the outcome of fetching a source address
+type Result struct {
+	// Path is the local path of the library, it is either a directory or an archive
+	Path string
+	// FetchError is set when the source could not be fetched and the cached content was used instead
+	FetchError error
+}
+
+// DefaultCacheDir returns the cache directory to use if none is configured.
+// This is the value of the ALZLIB_CACHE_DIR environment variable, if set,
+// otherwise a directory in the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	if dir := os.Getenv(CacheDirEnvVar); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine the cache directory, set the %s environment variable: %s", CacheDirEnvVar, err)
+	}
+	return filepath.Join(dir, "terraform-provider-alzlib"), nil
+}
+
+// source is a parsed source address
+type source struct {
+	// git is true for git repositories
+	git bool
+	// url is the address of the repository or archive, without the sub directory or ref
+	url string
+	// subdir is the directory within a git repository that contains the lib files
+	subdir string
+	// ref is the git branch, tag or commit
+	ref string
+	// ext is the file extension of an archive
+	ext string
+}
+
+// parseSource parses the supplied source address
+func parseSource(addr string) (source, error) {
+	s := source{}
+	raw := addr
+	if strings.HasPrefix(raw, gitPrefix) {
+		s.git = true
+		raw = strings.TrimPrefix(raw, gitPrefix)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return s, fmt.Errorf("invalid source address %s: %s", addr, err)
+	}
+
+	if s.git {
+		if u.Scheme != "https" && u.Scheme != "file" && u.Scheme != "ssh" {
+			return s, fmt.Errorf("invalid source address %s: git sources must use https, ssh or file", addr)
+		}
+		q := u.Query()
+		s.ref = q.Get("ref")
+		q.Del("ref")
+		if s.ref != "" && !validRef(s.ref) {
+			return s, fmt.Errorf("invalid source address %s: %q is not a valid git branch, tag or commit", addr, s.ref)
+		}
+		u.RawQuery = q.Encode()
+		// the sub directory is separated from the repository path by a double slash
+		if i := strings.Index(strings.TrimPrefix(u.Path, "/"), "//"); i >= 0 {
+			i++
+			s.subdir = strings.Trim(u.Path[i+2:], "/")
+			u.Path = u.Path[:i]
+			u.RawPath = ""
+		}
+		if strings.Contains(s.subdir, "..") {
+			return s, fmt.Errorf("invalid source address %s: the sub directory must be within the repository", addr)
+		}
+		s.url = u.String()
+		return s, nil
+	}
+
+	if u.Scheme != "https" {
+		return s, fmt.Errorf("invalid source address %s: it must be a git:: address or an https URL", addr)
+	}
+	s.ext = archiveExtension(u.Path)
+	if s.ext == "" {
+		return s, fmt.Errorf("invalid source address %s: https sources must be .zip or .tar.gz archives", addr)
+	}
+	s.url = u.String()
+	return s, nil
+}
+
+// Fetch fetches the source address into the cache and returns the local path of the library.
+// If the source cannot be fetched, the content that was last fetched from the same address is used, if it is in the cache.
+// If checksum is not empty, it is the expected hex encoded SHA-256 of an archive source.
+func (f *Fetcher) Fetch(ctx context.Context, addr, checksum string) (*Result, error) {
+	s, err := parseSource(addr)
+	if err != nil {
+		return nil, err
+	}
+	if s.git && checksum != "" {
+		return nil, fmt.Errorf("a sha256 checksum can only be used with archive sources, not %s", addr)
+	}
+
+	c := &cache{dir: f.CacheDir}
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+
+	var key string
+	var fetchErr error
+	if s.git {
+		key, fetchErr = f.fetchGit(ctx, c, s)
+	} else {
+		key, fetchErr = f.fetchArchive(ctx, c, s, checksum)
+	}
+
+	if fetchErr == nil {
+		if err := c.setIndex(addr, key); err != nil {
+			return nil, err
+		}
+		return &Result{Path: c.path(key, s.subdir)}, nil
+	}
+
+	// a checksum mismatch must not fall back to the cache
+	var mismatch *alzlib.ChecksumMismatchError
+	if errors.As(fetchErr, &mismatch) {
+		return nil, fetchErr
+	}
+
+	key, ok, err := c.lookupIndex(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("unable to fetch %s and it is not in the cache %s: %s", addr, c.dir, fetchErr)
+	}
+	if !s.git {
+		actual, err := c.sha256(key)
+		if err != nil {
+			return nil, fmt.Errorf("error reading cached archive for %s: %s", addr, err)
+		}
+		if err := alzlib.VerifySha256(actual, checksum); err != nil {
+			return nil, fmt.Errorf("cached archive for %s: %w", addr, err)
+		}
+	}
+	return &Result{Path: c.path(key, s.subdir), FetchError: fetchErr}, nil
+}
+
+// validRef returns true if the supplied ref is a full commit id or a valid git reference name,
+// following the rules of `git check-ref-format`. Refs cannot start with a dash, so they cannot be parsed as git options.
+func validRef(ref string) bool {
+	if commitRegex.MatchString(ref) {
+		return true
+	}
+	if ref == "@" || strings.HasPrefix(ref, "-") || strings.HasSuffix(ref, ".") ||
+		strings.Contains(ref, "..") || strings.Contains(ref, "@{") {
+		return false
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return false
+		}
+	}
+	for _, c := range strings.Split(ref, "/") {
+		if c == "" || strings.HasPrefix(c, ".") || strings.HasSuffix(c, ".lock") {
+			return false
+		}
+	}
+	return true
+}
+
+// archiveExtension returns the archive extension of the supplied path, or an empty string
+func archiveExtension(p string) string {
+	lp := strings.ToLower(p)
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(lp, ext) {
+			return ext
+		}
+	}
+	return ""
+}
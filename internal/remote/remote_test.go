@@ -0,0 +1,203 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/alzlib"
+	"gotest.tools/v3/assert"
+)
+
+// TestParseSource tests the parsing of source addresses
+func TestParseSource(t *testing.T) {
+	s, err := parseSource("git::https://example.com/org/lib.git//platform/lib?ref=v2.1.0")
+	assert.NilError(t, err)
+	assert.Assert(t, s.git)
+	assert.Equal(t, s.url, "https://example.com/org/lib.git")
+	assert.Equal(t, s.subdir, "platform/lib")
+	assert.Equal(t, s.ref, "v2.1.0")
+
+	s, err = parseSource("git::file:///srv/mirror/lib.git")
+	assert.NilError(t, err)
+	assert.Equal(t, s.url, "file:///srv/mirror/lib.git")
+	assert.Equal(t, s.subdir, "")
+	assert.Equal(t, s.ref, "")
+
+	s, err = parseSource("https://example.com/releases/lib-2.1.0.tar.gz")
+	assert.NilError(t, err)
+	assert.Assert(t, !s.git)
+	assert.Equal(t, s.ext, ".tar.gz")
+
+	_, err = parseSource("https://example.com/releases/lib")
+	assert.ErrorContains(t, err, "https sources must be .zip or .tar.gz archives")
+	_, err = parseSource("http://example.com/releases/lib.zip")
+	assert.ErrorContains(t, err, "it must be a git:: address or an https URL")
+	_, err = parseSource("git::https://example.com/lib.git//../etc")
+	assert.ErrorContains(t, err, "the sub directory must be within the repository")
+}
+
+// TestParseSourceRef tests that refs which are not valid git refs, including those that would be parsed as options, are rejected
+func TestParseSourceRef(t *testing.T) {
+	for _, ref := range []string{"v2.1.0", "main", "release/2.x", "0123456789abcdef0123456789abcdef01234567"} {
+		_, err := parseSource("git::https://example.com/lib.git?ref=" + ref)
+		assert.NilError(t, err, ref)
+	}
+	for _, ref := range []string{"--upload-pack=touch%20x", "-b", "a..b", "a%20b", "a:b", "refs/heads/", "/main", ".hidden", "main.lock", "a@{1}", "@", "v1."} {
+		_, err := parseSource("git::https://example.com/lib.git?ref=" + ref)
+		assert.ErrorContains(t, err, "is not a valid git branch, tag or commit", ref)
+	}
+}
+
+// TestFetchGit tests fetching a sub directory of a file:// git remote, and using the cache when it is unavailable
+func TestFetchGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := newTestRepo(t)
+	f := &Fetcher{CacheDir: t.TempDir()}
+	addr := "git::file://" + filepath.ToSlash(repo) + "//lib?ref=v1"
+
+	res, err := f.Fetch(context.Background(), addr, "")
+	assert.NilError(t, err)
+	assert.NilError(t, res.FetchError)
+	az, err := alzlib.NewAlzLib(res.Path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(az.PolicyDefinitions), 2)
+	_, err = os.Stat(filepath.Join(res.Path, "..", ".git"))
+	assert.Assert(t, os.IsNotExist(err), "the .git directory should not be cached")
+
+	// remove the remote, the cached content is used
+	assert.NilError(t, os.RemoveAll(repo))
+	offline, err := f.Fetch(context.Background(), addr, "")
+	assert.NilError(t, err)
+	assert.Assert(t, offline.FetchError != nil)
+	assert.Equal(t, offline.Path, res.Path)
+
+	// a different ref has not been cached
+	_, err = f.Fetch(context.Background(), "git::file://"+filepath.ToSlash(repo)+"//lib?ref=v2", "")
+	assert.ErrorContains(t, err, "it is not in the cache")
+}
+
+// TestFetchGitCommit tests fetching a ref that is a commit id
+func TestFetchGitCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := newTestRepo(t)
+	commit, err := runGit(context.Background(), repo, "rev-parse", "v1")
+	assert.NilError(t, err)
+	f := &Fetcher{CacheDir: t.TempDir()}
+	res, err := f.Fetch(context.Background(), "git::file://"+filepath.ToSlash(repo)+"//lib?ref="+commit, "")
+	assert.NilError(t, err)
+	assert.Equal(t, res.Path, (&cache{dir: f.CacheDir}).path("git-"+commit, "lib"))
+}
+
+// TestFetchGitChecksum tests that a checksum cannot be used with a git source
+func TestFetchGitChecksum(t *testing.T) {
+	f := &Fetcher{CacheDir: t.TempDir()}
+	_, err := f.Fetch(context.Background(), "git::https://example.com/lib.git", "abc")
+	assert.ErrorContains(t, err, "a sha256 checksum can only be used with archive sources")
+}
+
+// TestFetchArchive tests downloading an archive, verifying its checksum, and using the cache when it is unavailable
+func TestFetchArchive(t *testing.T) {
+	data, err := os.ReadFile("./testdata/lib.tar.gz")
+	assert.NilError(t, err)
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lib.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	f := &Fetcher{CacheDir: t.TempDir(), Client: srv.Client()}
+	addr := srv.URL + "/lib.tar.gz"
+
+	_, err = f.Fetch(context.Background(), addr, "0000")
+	assert.ErrorContains(t, err, "sha256 checksum mismatch")
+
+	res, err := f.Fetch(context.Background(), addr, checksum)
+	assert.NilError(t, err)
+	assert.Equal(t, filepath.Base(res.Path), "sha256-"+checksum+".tar.gz")
+	az, err := alzlib.NewAlzLib(res.Path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(az.PolicyDefinitions), 2)
+
+	// stop the server, the cached archive is used
+	srv.Close()
+	offline, err := f.Fetch(context.Background(), addr, checksum)
+	assert.NilError(t, err)
+	assert.Assert(t, offline.FetchError != nil)
+	assert.Equal(t, offline.Path, res.Path)
+
+	// the cached archive is verified
+	_, err = f.Fetch(context.Background(), addr, "0000")
+	assert.ErrorContains(t, err, "sha256 checksum mismatch")
+}
+
+// TestFetchArchiveMaxSize tests that archives larger than the maximum size are rejected,
+// whether or not the server sends the content length, and are not cached
+func TestFetchArchiveMaxSize(t *testing.T) {
+	data, err := os.ReadFile("./testdata/lib.tar.gz")
+	assert.NilError(t, err)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") != "" {
+			// flushing before writing the body means that the content length is not sent
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	for _, addr := range []string{srv.URL + "/lib.tar.gz", srv.URL + "/lib.tar.gz?chunked=true"} {
+		cacheDir := t.TempDir()
+		f := &Fetcher{CacheDir: cacheDir, Client: srv.Client(), MaxArchiveSize: int64(len(data) - 1)}
+		_, err := f.Fetch(context.Background(), addr, "")
+		assert.ErrorContains(t, err, "the archive is larger than the maximum size")
+		objects, err := os.ReadDir(filepath.Join(cacheDir, cacheObjectsDir))
+		assert.NilError(t, err)
+		assert.Equal(t, len(objects), 0)
+
+		f.MaxArchiveSize = int64(len(data))
+		_, err = f.Fetch(context.Background(), addr, "")
+		assert.NilError(t, err)
+	}
+
+	assert.Equal(t, defaultHTTPClient.Timeout, DownloadTimeout)
+}
+
+// newTestRepo creates a git repository containing the base test layer in a lib directory, tagged v1
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	repo := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(repo, "lib"), 0o755))
+	src := "../alzlib/testdata/layers/base"
+	entries, err := os.ReadDir(src)
+	assert.NilError(t, err)
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(src, e.Name()))
+		assert.NilError(t, err)
+		assert.NilError(t, os.WriteFile(filepath.Join(repo, "lib", e.Name()), data, 0o644))
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "lib"},
+		{"tag", "v1"},
+	} {
+		_, err := runGit(context.Background(), repo, args...)
+		assert.NilError(t, err)
+	}
+	return repo
+}
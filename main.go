@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"log"
+	"os"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/matt-FFFFFF/terraform-provider-alzlib/internal/provider"
@@ -29,6 +30,10 @@ var (
 )
 
 func main() {
+	if handled, err := runCommand(os.Args[1:], os.Stdout); handled {
+		os.Exit(commandExitStatus(err, os.Stderr))
+	}
+
 	var debug bool
 
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")